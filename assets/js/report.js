@@ -25,6 +25,44 @@ Handlebars.registerHelper('color', function(percentage, options) {
   };
 });
 
+// format a float number with one decimal
+Handlebars.registerHelper('fixed', function(n, options) {
+  return Number(n).toFixed(1);
+});
+
+Handlebars.registerHelper('percent', function(n, options) {
+  return parseInt(n * 100.0);
+});
+
+// ratio is a/b in percent, 100 if b is 0
+Handlebars.registerHelper('ratio', function(a, b, options) {
+  return b ? parseInt(a * 100.0 / b) : 100;
+});
+
+Handlebars.registerHelper('join', function(lines, options) {
+  return (lines || []).join("\n");
+});
+
+// suggestedFix applies the replacement of error to its source lines,
+// the same as types.Error.Fix.
+Handlebars.registerHelper('suggestedFix', function(err, options) {
+  var r = err.replacement;
+  if (r.need_only_delete) {
+    return "(delete lines)";
+  }
+  if (r.inline && err.source_lines && err.source_lines.length) {
+    var line = err.source_lines[0];
+    var start = r.inline.start_col, end = start + r.inline.length;
+    var fixed = line.substring(0, start) + r.inline.new_string + line.substring(end);
+    return [fixed].concat(err.source_lines.slice(1)).join("\n");
+  }
+  return (r.new_lines || []).join("\n");
+});
+
+Handlebars.registerHelper('istimeout', function(state, options) {
+  return state == "timeout";
+});
+
 Handlebars.registerHelper('isfalse', function(percentage, options) {
   return percentage == false;
 });
@@ -60,20 +98,32 @@ var populateResults = function(data, domain){
     $resultsText.html($(templates.grade(data)));
     var $table = $(".results");
     $table.html('<p class="panel-heading">Results</p>');
-    for (var i = 0; i < checks.length; i++) {
-        checks[i].percentage = parseInt(checks[i].percentage * 100.0);
-        var $headRow = $(templates.check(checks[i]));
-        $headRow.on("click", function(){
-        $(this).closest("nav").find(".is-active").removeClass("is-active");
-          $(this).toggleClass("is-active");
-        });
-        $headRow.appendTo($table);
-        if (i == 0) {
-            $headRow.toggleClass("is-active");
+    var appendChecks = function(checks, anchorPrefix, active) {
+        for (var i = 0; i < checks.length; i++) {
+            checks[i].percentage = parseInt(checks[i].percentage * 100.0);
+            checks[i].anchor = anchorPrefix + checks[i].name;
+            var $headRow = $(templates.check(checks[i]));
+            $headRow.on("click", function(){
+            $(this).closest("nav").find(".is-active").removeClass("is-active");
+              $(this).toggleClass("is-active");
+            });
+            $headRow.appendTo($table);
+            if (active && i == 0) {
+                $headRow.toggleClass("is-active");
+            }
+
+            var $details = $(templates.details(checks[i]));
+            $details.appendTo($resultsDetails);
         }
-
-        var $details = $(templates.details(checks[i]));
-        $details.appendTo($resultsDetails);
+    };
+    appendChecks(checks, "", true);
+
+    // test code is linted in a separate pass
+    var testChecks = data.test_scores || [];
+    if (testChecks.length) {
+        $table.append('<p class="panel-heading">Test code</p>');
+        $resultsDetails.append('<h2 class="title">Test code</h2>');
+        appendChecks(testChecks, "test-", false);
     }
     $(".container-suggestions").addClass('hidden');
     $(".container-results").removeClass('hidden').slideDown();
@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/yeqown/goreportcard/internal/types"
 
@@ -11,12 +13,14 @@ import (
 	"github.com/yeqown/log"
 )
 
-func runCli(dir string, verbose bool) error {
+func runCli(dir, base string, excludes []string, fix fixOption, verbose bool) error {
 	log.SetLogLevel(log.LevelError)
 
 	ctx := linter.Context{
-		Dir:    dir,
-		Branch: types.MasterBranch,
+		Dir:      dir,
+		Branch:   types.MasterBranch,
+		Excludes: excludes,
+		Base:     base,
 	}
 
 	r, err := linter.Lint(ctx)
@@ -26,20 +30,199 @@ func runCli(dir string, verbose bool) error {
 	}
 
 	fmt.Printf("Grade: %s (%.1f%%)\n", r.Grade, r.Average*100)
+	for _, v := range r.Vulnerabilities {
+		fixed := "no fixed version"
+		if v.Fixed != "" {
+			fixed = "fixed in " + v.Fixed
+		}
+		fmt.Printf("Vulnerability %s: %s@%s, %s, %s reachable: %s\n",
+			v.ID, v.Module, v.Version, fixed, v.Reachable, v.Summary)
+	}
+	if s := r.Security; s != nil {
+		fmt.Printf("Security: %s (%.1f%%), issues: %d, checks: %s\n",
+			s.Grade, s.Percentage*100, s.Issues, strings.Join(s.Checks, ", "))
+	}
+	fmt.Printf("Scoring: %s\n", r.Scoring)
 	fmt.Printf("FilesCount: %d\n", r.Files)
 	fmt.Printf("IssuesCount: %d\n", r.Issues)
+	if r.Base != "" {
+		fmt.Printf("Base: %s, ChangedFiles: %d, NewIssues: %d, ExistingIssues: %d\n",
+			r.Base, r.ChangedFiles, r.Issues, r.ExistingIssues)
+	}
+	if r.Cache != nil {
+		fmt.Printf("Cache: %d hits, %d misses\n", r.Cache.Hits, r.Cache.Misses)
+	}
+	if size := r.Size; size != nil {
+		fmt.Printf("Lines: %d (code: %d, comments: %d, blank: %d), Packages: %d, Exported: %d\n",
+			size.Lines, size.Code, size.Comments, size.Blanks, len(size.Packages), size.Exported)
+		if verbose {
+			for _, pkg := range size.Packages {
+				fmt.Printf("\tpackage %s: files: %d, lines: %d (code: %d, comments: %d, blank: %d), exported: %d\n",
+					pkg.Dir, pkg.Files, pkg.Lines, pkg.Code, pkg.Comments, pkg.Blanks, pkg.Exported)
+			}
+		}
+	}
+	if len(r.Dependencies) != 0 {
+		fmt.Printf("DependenciesCount: %d\n", len(r.Dependencies))
+		if verbose {
+			for _, dep := range r.Dependencies {
+				fmt.Printf("\tdependency %s %s", dep.Path, dep.Version)
+				if dep.Latest != "" && dep.Latest != dep.Version {
+					fmt.Printf(", latest: %s", dep.Latest)
+				}
+				if dep.Replace != "" {
+					fmt.Printf(", replaced by %s", dep.Replace)
+				}
+				fmt.Println()
+			}
+		}
+	}
+	if r.Suppressed != 0 {
+		fmt.Printf("SuppressedCount: %d\n", r.Suppressed)
+	}
+	if len(r.Excluded) != 0 {
+		fmt.Printf("ExcludedCount: %d\n", len(r.Excluded))
+		if verbose {
+			for _, p := range r.Excluded {
+				fmt.Printf("\texcluded %s by %s (%s)\n", p.Path, p.Rule, p.Source)
+			}
+		}
+	}
+	if len(r.Generated) != 0 {
+		fmt.Printf("GeneratedCount: %d\n", len(r.Generated))
+		if verbose {
+			for _, filename := range r.Generated {
+				fmt.Printf("\tgenerated %s\n", filename)
+			}
+		}
+	}
+	for _, m := range r.Modules {
+		fmt.Printf("Module %s (%s): %s (%.1f%%), files: %d, issues: %d\n",
+			m.Path, m.Dir, m.Grade, m.Average*100, m.Files, m.Issues)
+	}
 
 	for _, score := range r.Scores {
+		printScore(score, verbose)
+	}
+
+	if len(r.TestScores) != 0 {
+		graded := "not counted in grade"
+		if r.TestsGraded {
+			graded = "counted in grade"
+		}
+		fmt.Printf("\nTest code (%s), FilesCount: %d, IssuesCount: %d\n", graded, r.TestFiles, r.TestIssues)
+		for _, score := range r.TestScores {
+			printScore(score, verbose)
+		}
+	}
+
+	if fix.enabled {
+		if err = runFix(ctx, r, fix); err != nil {
+			return err
+		}
+	}
+	if r.Base != "" && r.Issues != 0 {
+		// fail CI on new issues only
+		return errors.Errorf("%d new issues since %s", r.Issues, r.Base)
+	}
+	return nil
+}
+
+// printScore prints percentage, statistics and issues (in verbose mode) of score
+func printScore(score types.Score, verbose bool) {
+	switch score.State {
+	case types.ScoreTimeout:
+		fmt.Printf("%s: %s\n", score.Name, score.Error)
+		return
+	case types.ScoreFailed:
+		fmt.Printf("%s: %d%% (failed: %s)\n", score.Name, int64(score.Percentage*100), score.Error)
+	default:
 		fmt.Printf("%s: %d%%\n", score.Name, int64(score.Percentage*100))
-		if verbose && len(score.Summaries) > 0 {
-			for _, summary := range score.Summaries {
-				fmt.Printf("\t%s\n", summary.Filename)
-				for _, err := range summary.Errors {
-					fmt.Printf("\t\tLine %d: %s\n", err.LineNumber, err.ErrorString)
+	}
+	if score.Existing != 0 {
+		fmt.Printf("\texisting issues on unchanged lines: %d\n", score.Existing)
+	}
+	if l := score.License; l != nil {
+		switch {
+		case l.File == "":
+			fmt.Printf("\tlicense: no license file found\n")
+		case l.SPDX == "":
+			fmt.Printf("\tlicense: %s is not recognized\n", l.File)
+		default:
+			fmt.Printf("\tlicense: %s (%s, %.0f%% confidence)\n", l.SPDX, l.File, l.Confidence*100)
+		}
+	}
+	if c := score.Complexity; c != nil {
+		fmt.Printf("\tfunctions: %d, cyclomatic(avg/p90/max): %.1f/%.0f/%.0f, cognitive(avg/p90/max): %.1f/%.0f/%.0f\n",
+			c.Functions, c.Cyclomatic.Average, c.Cyclomatic.P90, c.Cyclomatic.Max,
+			c.Cognitive.Average, c.Cognitive.P90, c.Cognitive.Max)
+	}
+	if d := score.Docs; d != nil {
+		fmt.Printf("\tdocumented: %d of %d exported, %d not starting with the name\n", d.Documented, d.Exported, d.Misnamed)
+		if verbose {
+			for _, pkg := range d.Packages {
+				fmt.Printf("\tpackage %s: %d of %d documented (%.0f%%)\n",
+					pkg.Dir, pkg.Documented, pkg.Exported, pkg.Coverage()*100)
+			}
+		}
+	}
+	if d := score.Duplicates; d != nil {
+		fmt.Printf("\tduplicated: %d of %d lines in %d clone groups\n", d.DuplicatedLines, d.Lines, len(d.Groups))
+		if verbose {
+			for _, group := range d.Groups {
+				locations := make([]string, 0, len(group.Locations))
+				for _, loc := range group.Locations {
+					locations = append(locations, fmt.Sprintf("%s:%d-%d", loc.Filename, loc.StartLine, loc.EndLine))
 				}
+				fmt.Printf("\tclone of %d tokens: %s\n", group.Tokens, strings.Join(locations, ", "))
+			}
+		}
+	}
+	if verbose && len(score.Summaries) > 0 {
+		for _, summary := range score.Summaries {
+			fmt.Printf("\t%s\n", summary.Filename)
+			for _, err := range summary.Errors {
+				printError(err)
+			}
+			if summary.Diff != "" {
+				fmt.Printf("\t\t%s\n", strings.ReplaceAll(strings.TrimSpace(summary.Diff), "\n", "\n\t\t"))
 			}
 		}
 	}
+	if verbose && len(score.Suppressed) > 0 {
+		fmt.Printf("\tsuppressed:\n")
+		for _, issue := range score.Suppressed {
+			fmt.Printf("\t\t%s:%d: %s (reason: %s)\n",
+				issue.Filename, issue.Error.LineNumber, issue.Error.ErrorString, issue.Reason)
+		}
+	}
+}
 
-	return nil
+// printError prints position, rule and message of error, then source lines
+// and the suggested fix if any.
+func printError(err types.Error) {
+	pos := strconv.Itoa(err.LineNumber)
+	if err.Column > 0 {
+		pos += ":" + strconv.Itoa(err.Column)
+	}
+	if err.Rule != "" {
+		pos += " [" + err.Rule + "]"
+	}
+	switch {
+	case err.Severity != "" && err.Confidence != "":
+		pos += " (" + err.Severity + ", " + err.Confidence + " confidence)"
+	case err.Severity != "":
+		pos += " (" + err.Severity + ")"
+	}
+	fmt.Printf("\t\tLine %s: %s\n", pos, err.ErrorString)
+
+	for _, line := range err.SourceLines {
+		fmt.Printf("\t\t\t%s\n", line)
+	}
+	if fixed, ok := err.Fix(); ok {
+		fmt.Printf("\t\tfix:\n")
+		for _, line := range fixed {
+			fmt.Printf("\t\t\t%s\n", line)
+		}
+	}
 }
@@ -48,8 +48,12 @@ func getStartServerCommand() *cli.Command {
 
 func getCliCheckCommand() *cli.Command {
 	var (
-		dir     string
-		verbose bool
+		dir      string
+		verbose  bool
+		base     string
+		fix      fixOption
+		home, _  = os.UserHomeDir()
+		confPath = filepath.Join(home, "goreportcard.toml")
 	)
 
 	return &cli.Command{
@@ -67,9 +71,52 @@ func getCliCheckCommand() *cli.Command {
 				Usage:       "to show more detail about lint result",
 				Destination: &verbose,
 			},
+			&cli.StringFlag{
+				Name:        "base",
+				Usage:       "grade only issues on lines changed since the base ref, such as --base origin/main",
+				Destination: &base,
+			},
+			&cli.BoolFlag{
+				Name:        "fix",
+				Usage:       "apply fixes of golangci-lint and gofmt automatically, then show grade before and after",
+				Destination: &fix.enabled,
+			},
+			&cli.StringFlag{
+				Name:        "fix-output",
+				Usage:       "where fixes would be written, \"inplace\" to change files or \"patch\" to write a patch file",
+				Value:       fixInPlace,
+				Destination: &fix.output,
+			},
+			&cli.StringFlag{
+				Name:        "patch",
+				Usage:       "path of patch file when --fix-output=patch",
+				Value:       "goreportcard-fix.patch",
+				Destination: &fix.patch,
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude",
+				Usage: "glob of paths relative to dir to exclude, could be repeated, such as --exclude 'gen/**'",
+			},
+			&cli.StringFlag{
+				Name:        "conf",
+				Usage:       "specify a path to config, default is ~/goreportcard.toml if exists",
+				Value:       confPath,
+				Destination: &confPath,
+			},
 		},
 		Action: func(c *cli.Context) error {
-			return runCli(dir, verbose)
+			// default config would be used, if no config file
+			if _, err := os.Stat(confPath); err == nil || c.IsSet("conf") {
+				if err := types.Init(confPath); err != nil {
+					return errors.Wrap(err, "LoadConfig failed")
+				}
+			}
+
+			if err := fix.validate(); err != nil {
+				return err
+			}
+
+			return runCli(dir, base, c.StringSlice("exclude"), fix, verbose)
 		},
 	}
 }
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/yeqown/goreportcard/internal/linter"
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+// outputs of fixes
+const (
+	fixInPlace = "inplace" // write fixes into files
+	fixPatch   = "patch"   // write fixes into a unified patch file
+)
+
+// fixOption is options of `run --fix`
+type fixOption struct {
+	enabled bool
+	output  string // fixInPlace or fixPatch
+	patch   string // path of patch file
+}
+
+func (o fixOption) validate() error {
+	switch o.output {
+	case fixInPlace, fixPatch:
+		return nil
+	}
+	return errors.Errorf("invalid --fix-output %q, it should be %q or %q", o.output, fixInPlace, fixPatch)
+}
+
+// runFix applies automatic fixes of result, and lints again to show the
+// grade before and after. In patch mode, files in ctx.Dir are never changed,
+// the grade after is linted on a temporary copy of ctx.Dir, which is a git
+// worktree if ctx.Base is set, so changes since Base are the same.
+func runFix(ctx linter.Context, before types.LintResult, opt fixOption) error {
+	fixes, err := linter.Fixes(ctx.Dir, before)
+	if err != nil {
+		return errors.Wrap(err, "runFix.Fixes")
+	}
+	if len(fixes) == 0 {
+		fmt.Printf("\nNo auto-fixable issues\n")
+		return nil
+	}
+
+	fixed := 0
+	fmt.Printf("\nFixes:\n")
+	for _, fix := range fixes {
+		fmt.Printf("\t%s: %d\n", fix.Filename, fix.Fixes)
+		fixed += fix.Fixes
+	}
+
+	switch opt.output {
+	case fixPatch:
+		patch, err := linter.Patch(fixes)
+		if err != nil {
+			return err
+		}
+		if err = ioutil.WriteFile(opt.patch, []byte(patch), 0644); err != nil {
+			return errors.Wrap(err, "runFix.WriteFile")
+		}
+		fmt.Printf("%d fixes of %d files are written into %s\n", fixed, len(fixes), opt.patch)
+
+		tmp, err := ioutil.TempDir("", "goreportcard-fix-*")
+		if err != nil {
+			return errors.Wrap(err, "runFix.TempDir")
+		}
+		defer os.RemoveAll(tmp)
+
+		dir := tmp
+		if ctx.Base != "" {
+			remove, err := addWorktree(ctx.Dir, tmp)
+			if err != nil {
+				return err
+			}
+			defer remove()
+			if dir, err = worktreeDir(ctx.Dir, tmp); err != nil {
+				return err
+			}
+		}
+		if err = copyDir(ctx.Dir, dir); err != nil {
+			return err
+		}
+		if ctx.Base != "" {
+			if err = removeDeleted(ctx.Dir, dir); err != nil {
+				return err
+			}
+		}
+		ctx.Dir = dir
+	default:
+		fmt.Printf("%d fixes of %d files are applied\n", fixed, len(fixes))
+	}
+
+	if err = linter.WriteFixes(ctx.Dir, fixes); err != nil {
+		return err
+	}
+	after, err := linter.Lint(ctx)
+	if err != nil {
+		return errors.Wrap(err, "runFix.Lint")
+	}
+
+	fmt.Printf("Grade: %s (%.1f%%) -> %s (%.1f%%), IssuesCount: %d -> %d\n",
+		before.Grade, before.Average*100, after.Grade, after.Average*100, before.Issues, after.Issues)
+	return nil
+}
+
+// addWorktree checks out HEAD of the repo of dir into tmp as a detached git
+// worktree, remove removes the worktree from the repo.
+func addWorktree(dir, tmp string) (remove func(), err error) {
+	if _, err = git(dir, "worktree", "add", "--detach", "--quiet", tmp, "HEAD"); err != nil {
+		return nil, errors.Wrap(err, "addWorktree")
+	}
+
+	return func() {
+		if _, err := git(dir, "worktree", "remove", "--force", tmp); err != nil {
+			fmt.Printf("could not remove git worktree %s: %v\n", tmp, err)
+		}
+	}, nil
+}
+
+// worktreeDir returns the dir in worktree tmp which is the same as dir in
+// its repo, dir could be a sub-directory of repo.
+func worktreeDir(dir, tmp string) (string, error) {
+	prefix, err := git(dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return "", errors.Wrap(err, "worktreeDir")
+	}
+	return filepath.Join(tmp, filepath.FromSlash(strings.TrimSpace(prefix))), nil
+}
+
+// removeDeleted removes files from dst which are deleted in the working tree
+// of src but not committed.
+func removeDeleted(src, dst string) error {
+	deleted, err := git(src, "ls-files", "-z", "--deleted", "--", ".")
+	if err != nil {
+		return errors.Wrap(err, "removeDeleted")
+	}
+
+	for _, file := range strings.Split(deleted, "\x00") {
+		if file == "" {
+			continue
+		}
+		if err = os.Remove(filepath.Join(dst, filepath.FromSlash(file))); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "removeDeleted.Remove")
+		}
+	}
+	return nil
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// copyDir copies regular files in src into dst, .git is skipped.
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case fi.IsDir() && fi.Name() == ".git":
+			return filepath.SkipDir
+		case fi.IsDir():
+			return os.MkdirAll(target, 0755)
+		case !fi.Mode().IsRegular():
+			return nil
+		}
+
+		return copyFile(path, target, fi.Mode())
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.Wrap(err, "copyFile.Open")
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return errors.Wrap(err, "copyFile.OpenFile")
+	}
+	defer out.Close()
+
+	if _, err = io.Copy(out, in); err != nil {
+		return errors.Wrap(err, "copyFile.Copy")
+	}
+	return nil
+}
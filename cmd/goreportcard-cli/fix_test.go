@@ -0,0 +1,105 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// gofmt is the only linter, so golangci-lint is not required.
+const _fixTestConfig = `
+[tests]
+    linters = []
+
+[[linters]]
+    name = "gofmt"
+    type = "native"
+    weight = 1.0
+`
+
+func initGitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch=main"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "init"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v, %s", args[0], err, out)
+		}
+	}
+	return dir
+}
+
+func Test_runCommand_fixPatchWithBase(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not found")
+	}
+
+	dir := initGitRepo(t, map[string]string{
+		"go.mod": "module example.com/fix\n\ngo 1.20\n",
+		"a.go":   "package fix\n\nfunc A() int {\n\treturn 1\n}\n",
+		"b.go":   "package fix\n\nfunc B() int {\n\treturn 2\n}\n",
+	})
+	// changed and untracked files are not formatted, b.go is deleted
+	changed := "package fix\n\nfunc A() int {\n  return   1\n}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.go"), []byte(changed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "c.go"), []byte("package fix\nfunc C() int {return 3}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "b.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	tmp := t.TempDir()
+	conf := filepath.Join(tmp, "goreportcard.toml")
+	if err := ioutil.WriteFile(conf, []byte(_fixTestConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	patch := filepath.Join(tmp, "fix.patch")
+
+	app := cli.NewApp()
+	mountCommands(app)
+	err := app.Run([]string{"goreportcard-cli", "run",
+		"--dir", dir, "--base", "main", "--conf", conf,
+		"--fix", "--fix-output", fixPatch, "--patch", patch,
+	})
+	// issues before fixes are graded, so CI still fails on them
+	if want := "2 new issues since main"; err == nil || err.Error() != want {
+		t.Fatalf("run --fix --base error = %v, want %q", err, want)
+	}
+
+	data, err := ioutil.ReadFile(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"a.go", "c.go"} {
+		if !strings.Contains(string(data), "+++ b/"+file) {
+			t.Errorf("patch should fix %s, got:\n%s", file, data)
+		}
+	}
+	if got, _ := ioutil.ReadFile(filepath.Join(dir, "a.go")); string(got) != changed {
+		t.Errorf("a.go should not be changed in patch mode, got:\n%s", got)
+	}
+	out, err := exec.Command("git", "-C", dir, "worktree", "list", "--porcelain").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(out), "worktree "); n != 1 {
+		t.Errorf("temporary worktree should be removed, got:\n%s", out)
+	}
+}
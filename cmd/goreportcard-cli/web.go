@@ -38,6 +38,7 @@ func startWebServer(cfg *types.Config) error {
 		return errors.Wrapf(err, "os mkdir in: %s", cfg.RepoRoot)
 	}
 
+	httpapi.LoadTemplates()
 	assetHdl := httpapi.NewAssetsHandler()
 	http.HandleFunc("/", withMetrics(httpapi.HomeHandler))
 	http.HandleFunc("/assets/", withMetrics(assetHdl.Assets))
@@ -53,7 +53,7 @@ func (hdl *assetsHandler) Badge(w http.ResponseWriter, req *http.Request, p *typ
 	}
 
 	// not found in cache, then reload from lint
-	r, err := doling(p, false)
+	r, err := doling(req.Context(), p, false)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"param": p,
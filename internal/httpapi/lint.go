@@ -13,17 +13,22 @@ import (
 const (
 	_repoFormKey   = "repo"
 	_branchFormKey = "branch"
+	_baseFormKey   = "base"
 )
 
-// LintHandler handles the request for checking a repo
+// LintHandler handles the request for checking a repo. If base is set, only
+// issues on lines changed since base are graded, and the report is responded
+// directly rather than redirecting to the report page.
 func LintHandler(w http.ResponseWriter, r *http.Request) {
 	repo := r.FormValue(_repoFormKey)
 	branch := r.FormValue(_branchFormKey)
+	base := r.FormValue(_baseFormKey)
 
 	// TODO: valid repo format "github.com/xxx/xxx"
 	log.WithFields(log.Fields{
 		"repo":   repo,
 		"branch": branch,
+		"base":   base,
 	}).Infof("checking repo")
 
 	if branch == "" {
@@ -34,7 +39,22 @@ func LintHandler(w http.ResponseWriter, r *http.Request) {
 	forceRefresh := r.Method != "GET"
 	p := types.NewRepoParam(repo, branch)
 
-	_, err := doling(p, forceRefresh)
+	if base != "" {
+		report, err := dolingBase(r.Context(), p, base)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"error": err,
+			}).Errorf("dolingBase failed")
+
+			Error(w, http.StatusBadRequest, errors.Wrap(err, "Could not analyze the changes"))
+			return
+		}
+
+		JSON(w, http.StatusOK, report)
+		return
+	}
+
+	_, err := doling(r.Context(), p, forceRefresh)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"error": err,
@@ -2,6 +2,7 @@ package httpapi
 
 import (
 	"container/heap"
+	"context"
 	"encoding/json"
 	"strings"
 	"time"
@@ -16,8 +17,9 @@ import (
 	"github.com/yeqown/log"
 )
 
-// executing golangci-lint tool, and return result
-func doling(p *types.RepoReportParam, forceRefresh bool) (result types.LintReport, err error) {
+// executing golangci-lint tool, and return result, linting is canceled
+// if reqCtx is done, such as the client disconnected.
+func doling(reqCtx context.Context, p *types.RepoReportParam, forceRefresh bool) (result types.LintReport, err error) {
 	log.WithFields(log.Fields{
 		"param":        p,
 		"forceRefresh": forceRefresh,
@@ -43,28 +45,16 @@ func doling(p *types.RepoReportParam, forceRefresh bool) (result types.LintRepor
 
 	// execute lint.Lint
 	ctx := linter.Context{
+		Ctx:    reqCtx,
 		Dir:    root,
 		Branch: p.Branch(),
+		Cache:  repository.GetRepo(),
 	}
 	var r types.LintResult
 	if r, err = linter.Lint(ctx); err != nil {
 		return
 	}
-
-	t := time.Now().UTC()
-	lintResult := types.LintReport{
-		Scores:               r.Scores,
-		Average:              r.Average,
-		Grade:                r.Grade,
-		FilesCount:           r.Files,
-		IssuesCount:          r.Issues,
-		Repo:                 p.Repo(),
-		ResolvedRepo:         p.Repo(),
-		Branch:               p.Branch(),
-		LastRefresh:          t,
-		LastRefreshFormatted: t.Format(time.UnixDate),
-		LastRefreshHumanized: humanize.Time(t),
-	}
+	lintResult := newLintReport(p, r)
 
 	var (
 		isNewRepo bool // current repoIdentity is first encounter with goreportcard
@@ -92,6 +82,65 @@ func doling(p *types.RepoReportParam, forceRefresh bool) (result types.LintRepor
 	return lintResult, nil
 }
 
+// dolingBase lints the repo in diff-aware mode, only issues on lines changed
+// since base are graded. The report is neither stored nor counted in metadata,
+// since it's not the report of the whole repo.
+func dolingBase(reqCtx context.Context, p *types.RepoReportParam, base string) (types.LintReport, error) {
+	root, err := vcshelper.GetDownloader().Download(p.Repo(), types.GetConfig().RepoRoot, p.Branch())
+	if err != nil {
+		return types.LintReport{}, errors.Errorf("could not clone repoIdentity: %v", err)
+	}
+
+	ctx := linter.Context{
+		Ctx:    reqCtx,
+		Dir:    root,
+		Branch: p.Branch(),
+		Base:   base,
+		Cache:  repository.GetRepo(),
+	}
+	r, err := linter.Lint(ctx)
+	if err != nil {
+		return types.LintReport{}, err
+	}
+
+	return newLintReport(p, r), nil
+}
+
+// newLintReport creates LintReport of repo from LintResult
+func newLintReport(p *types.RepoReportParam, r types.LintResult) types.LintReport {
+	t := time.Now().UTC()
+	return types.LintReport{
+		Scores:               r.Scores,
+		Average:              r.Average,
+		Grade:                r.Grade,
+		FilesCount:           r.Files,
+		IssuesCount:          r.Issues,
+		SuppressedCount:      r.Suppressed,
+		Scoring:              r.Scoring,
+		Modules:              r.Modules,
+		TestScores:           r.TestScores,
+		TestFilesCount:       r.TestFiles,
+		TestIssuesCount:      r.TestIssues,
+		TestsGraded:          r.TestsGraded,
+		Excluded:             r.Excluded,
+		GeneratedFiles:       r.Generated,
+		Size:                 r.Size,
+		Base:                 r.Base,
+		ChangedFilesCount:    r.ChangedFiles,
+		ExistingIssuesCount:  r.ExistingIssues,
+		Cache:                r.Cache,
+		Dependencies:         r.Dependencies,
+		Vulnerabilities:      r.Vulnerabilities,
+		Security:             r.Security,
+		Repo:                 p.Repo(),
+		ResolvedRepo:         p.Repo(),
+		Branch:               p.Branch(),
+		LastRefresh:          t,
+		LastRefreshFormatted: t.Format(time.UnixDate),
+		LastRefreshHumanized: humanize.Time(t),
+	}
+}
+
 // lintResultKey . to generate db.Key of lint result
 func lintResultKey(p *types.RepoReportParam) []byte {
 	return []byte("repos-" + p.RepoIdentity())
@@ -284,12 +333,16 @@ func updateHighScores(result types.LintReport, p *types.RepoReportParam) (err er
 
 	// now we can safely push it onto the heap
 	heap.Init(scores)
-	heap.Push(scores, scoreItem{
+	item := scoreItem{
 		Repo:   p.Repo(),
 		Branch: p.Branch(),
 		Score:  result.Average * 100.0,
 		Files:  result.FilesCount,
-	})
+	}
+	if result.Security != nil {
+		item.SecurityGrade = result.Security.Grade
+	}
+	heap.Push(scores, item)
 
 	if len(*scores) > 50 {
 		// trim heap if it's grown to over 50
@@ -145,9 +145,23 @@ func HighScoresHandler(w http.ResponseWriter, r *http.Request) {
 		sortedScores[len(sortedScores)-i-1] = heap.Pop(&scores).(scoreItem)
 	}
 
+	// filter by security grade, such as ?security=A
+	security := types.Grade(r.URL.Query().Get("security"))
+	if security != "" {
+		filtered := sortedScores[:0]
+		for _, item := range sortedScores {
+			if item.SecurityGrade == security {
+				filtered = append(filtered, item)
+			}
+		}
+		sortedScores = filtered
+	}
+
 	data := map[string]interface{}{
 		"HighScores": sortedScores,
 		"Count":      reposCount,
+		"Security":   security,
+		"Grades":     []types.Grade{types.GradeAPlus, types.GradeA, types.GradeB, types.GradeC, types.GradeD, types.GradeE, types.GradeF},
 	}
 	renderHTML(w, http.StatusOK, tplHighscore, data)
 }
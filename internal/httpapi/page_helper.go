@@ -19,11 +19,9 @@ var (
 	tplAbout     *template.Template
 )
 
-func init() {
-	loadTpls()
-}
-
-func loadTpls() {
+// LoadTemplates parses templates of pages in tpl/ of working dir, it should
+// be called before serving pages, and panics if templates could not be parsed.
+func LoadTemplates() {
 	tpl404 = template.Must(
 		template.New("404.html").Delims("[[", "]]").
 			ParseFiles("tpl/404.html", "tpl/footer.html", "tpl/header.html"))
@@ -3,6 +3,8 @@ package httpapi
 import (
 	"container/heap"
 	"sort"
+
+	"github.com/yeqown/goreportcard/internal/types"
 )
 
 var (
@@ -15,6 +17,8 @@ type scoreItem struct {
 	Branch string  `json:"branch"`
 	Score  float64 `json:"score"`
 	Files  int     `json:"files"`
+	// SecurityGrade is the sub-grade of security checks, empty if none is enabled
+	SecurityGrade types.Grade `json:"security_grade,omitempty"`
 }
 
 // An ScoreHeap is a min-heap of int array.
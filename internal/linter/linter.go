@@ -20,3 +20,14 @@ type ILinter interface {
 	// as well as a map of filename to output
 	Execute(ctx Context) (float64, []types.FileSummary, error)
 }
+
+// IDetailLinter is an optional interface of ILinter, linters which have more
+// results than summaries to report, such as statistics of the repo, should
+// implement it. Lint calls ExecuteDetail instead of Execute if implemented.
+type IDetailLinter interface {
+	ILinter
+
+	// ExecuteDetail executes the check like Execute, and fills the result
+	// into score, including Percentage and Summaries.
+	ExecuteDetail(ctx Context, score *types.Score) error
+}
@@ -0,0 +1,259 @@
+package linter
+
+import (
+	"go/token"
+	"path/filepath"
+	"sync"
+
+	"github.com/gordonklaus/ineffassign/pkg/ineffassign"
+	"github.com/pkg/errors"
+	"github.com/yeqown/goreportcard/internal/types"
+	"github.com/yeqown/log"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/analysis/passes/asmdecl"
+	"golang.org/x/tools/go/analysis/passes/assign"
+	"golang.org/x/tools/go/analysis/passes/atomic"
+	"golang.org/x/tools/go/analysis/passes/bools"
+	"golang.org/x/tools/go/analysis/passes/buildtag"
+	"golang.org/x/tools/go/analysis/passes/cgocall"
+	"golang.org/x/tools/go/analysis/passes/composite"
+	"golang.org/x/tools/go/analysis/passes/copylock"
+	"golang.org/x/tools/go/analysis/passes/errorsas"
+	"golang.org/x/tools/go/analysis/passes/httpresponse"
+	"golang.org/x/tools/go/analysis/passes/loopclosure"
+	"golang.org/x/tools/go/analysis/passes/lostcancel"
+	"golang.org/x/tools/go/analysis/passes/nilfunc"
+	"golang.org/x/tools/go/analysis/passes/nilness"
+	"golang.org/x/tools/go/analysis/passes/printf"
+	"golang.org/x/tools/go/analysis/passes/shadow"
+	"golang.org/x/tools/go/analysis/passes/shift"
+	"golang.org/x/tools/go/analysis/passes/stdmethods"
+	"golang.org/x/tools/go/analysis/passes/structtag"
+	"golang.org/x/tools/go/analysis/passes/unmarshal"
+	"golang.org/x/tools/go/analysis/passes/unreachable"
+	"golang.org/x/tools/go/analysis/passes/unsafeptr"
+	"golang.org/x/tools/go/analysis/passes/unusedresult"
+	"golang.org/x/tools/go/analysis/passes/unusedwrite"
+	"golang.org/x/tools/go/packages"
+)
+
+// _analyzers could be used by analysis linters, key is the name of linter.
+// "vet" contains passes which `go vet` runs by default.
+var _analyzers = map[string][]*analysis.Analyzer{
+	"vet": {
+		asmdecl.Analyzer, assign.Analyzer, atomic.Analyzer, bools.Analyzer,
+		buildtag.Analyzer, cgocall.Analyzer, composite.Analyzer, copylock.Analyzer,
+		errorsas.Analyzer, httpresponse.Analyzer, loopclosure.Analyzer, lostcancel.Analyzer,
+		nilfunc.Analyzer, printf.Analyzer, shift.Analyzer, stdmethods.Analyzer,
+		structtag.Analyzer, unmarshal.Analyzer, unreachable.Analyzer, unsafeptr.Analyzer,
+		unusedresult.Analyzer,
+	},
+	"ineffassign": {ineffassign.Analyzer},
+	"nilness":     {nilness.Analyzer},
+	"shadow":      {shadow.Analyzer},
+	"unusedwrite": {unusedwrite.Analyzer},
+}
+
+var _ ILinter = analyzer{}
+
+// analyzer runs go/analysis analyzers in process, so it works without
+// golangci-lint binary. All analyzer linters of one Lint call share packages
+// which are loaded only once, see analysisRunner.
+type analyzer struct {
+	name   string  // linter's name
+	desc   string  // linter's desc
+	weight float64 // linter's weight
+
+	analyzers []*analysis.Analyzer
+}
+
+func newAnalyzer(opt *types.LinterOption) (analyzer, error) {
+	analyzers, ok := _analyzers[opt.Name]
+	if !ok {
+		return analyzer{}, errors.Errorf("no such analysis linter %q", opt.Name)
+	}
+
+	return analyzer{
+		name:      opt.Name,
+		desc:      opt.Desc,
+		weight:    opt.Weight,
+		analyzers: analyzers,
+	}, nil
+}
+
+func (a analyzer) Name() string {
+	return a.name
+}
+
+func (a analyzer) Description() string {
+	return a.desc
+}
+
+func (a analyzer) Weight() float64 {
+	return a.weight
+}
+
+func (a analyzer) Execute(ctx Context) (float64, []types.FileSummary, error) {
+	runner := ctx.analysis
+	if runner == nil {
+		// not called by Lint, so run analyzers only for this linter
+		runner = newAnalysisRunner([]analyzer{a}, ctx.tests)
+	}
+
+	issues, err := runner.run(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	summaries := issues[a.name]
+	p, err := calcPercentage(ctx, summaries)
+	return p, summaries, err
+}
+
+// analysisRunner loads packages once and runs analyzers of all analysis linters,
+// then diagnostics are split by the linter which the analyzer belongs to.
+type analysisRunner struct {
+	once      sync.Once
+	analyzers []*analysis.Analyzer
+	owners    map[*analysis.Analyzer]string // map[analyzer]linterName
+	tests     bool                          // load test packages or not
+	patterns  []string                      // packages to load, all packages if empty
+
+	issues map[string][]types.FileSummary // map[linterName]summaries
+	err    error
+}
+
+func newAnalysisRunner(linters []analyzer, tests bool) *analysisRunner {
+	r := &analysisRunner{
+		tests:     tests,
+		analyzers: make([]*analysis.Analyzer, 0, len(linters)),
+		owners:    make(map[*analysis.Analyzer]string, len(linters)),
+	}
+	for _, l := range linters {
+		for _, a := range l.analyzers {
+			if _, ok := r.owners[a]; ok {
+				continue
+			}
+			r.analyzers = append(r.analyzers, a)
+			r.owners[a] = l.name
+		}
+	}
+
+	return r
+}
+
+// run loads packages and executes analyzers at the first call, and the others
+// wait and share the same result.
+func (r *analysisRunner) run(ctx Context) (map[string][]types.FileSummary, error) {
+	r.once.Do(func() {
+		r.issues, r.err = r.analyze(ctx.shared())
+	})
+
+	return r.issues, r.err
+}
+
+func (r *analysisRunner) analyze(ctx Context) (map[string][]types.FileSummary, error) {
+	dir, err := filepath.Abs(ctx.Dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "analysisRunner.Abs")
+	}
+
+	cfg := &packages.Config{
+		Context: ctx.stdContext(),
+		Mode:    packages.LoadAllSyntax,
+		Dir:     dir,
+		Tests:   r.tests,
+	}
+	patterns := r.patterns
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, errors.Wrap(err, "analysisRunner.packages.Load")
+	}
+
+	graph, err := checker.Analyze(r.analyzers, pkgs, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "analysisRunner.checker.Analyze")
+	}
+
+	// only files which are visited by visitGoFiles would be reported
+	filenames := make(map[string]struct{}, len(ctx.Filenames))
+	for _, filename := range ctx.Filenames {
+		abs, _ := filepath.Abs(filename)
+		filenames[abs] = struct{}{}
+	}
+
+	collectors := make(map[string]*summaryCollector, len(r.owners))
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			// packages with errors, such as type errors, could not be analyzed
+			log.Warnf("analysisRunner: analyzer=%s failed on package=%s, err=%v",
+				act.Analyzer.Name, act.Package.PkgPath, act.Err)
+			continue
+		}
+
+		linterName := r.owners[act.Analyzer]
+		for _, diag := range act.Diagnostics {
+			pos := act.Package.Fset.Position(diag.Pos)
+			if _, ok := filenames[pos.Filename]; !ok {
+				continue
+			}
+
+			collector, ok := collectors[linterName]
+			if !ok {
+				collector = newSummaryCollector(ctx)
+				collectors[linterName] = collector
+			}
+
+			// category is a subkind of diagnostics, not a severity
+			rule := act.Analyzer.Name
+			if diag.Category != "" {
+				rule += "/" + diag.Category
+			}
+			filename, _ := filepath.Rel(dir, pos.Filename)
+			collector.add(filename, types.Error{
+				LineNumber:  pos.Line,
+				Column:      pos.Column,
+				Rule:        rule,
+				Severity:    defaultSeverity(linterName, ""),
+				ErrorString: diag.Message,
+				Replacement: suggestedReplacement(act.Package.Fset, diag),
+			})
+		}
+	}
+
+	issues := make(map[string][]types.FileSummary, len(collectors))
+	for linterName, collector := range collectors {
+		issues[linterName] = collector.summaries()
+	}
+
+	return issues, nil
+}
+
+// suggestedReplacement converts the first suggested fix of diagnostic into
+// types.Replacement, only the fix which edits in one line is supported.
+func suggestedReplacement(fset *token.FileSet, diag analysis.Diagnostic) *types.Replacement {
+	if len(diag.SuggestedFixes) == 0 || len(diag.SuggestedFixes[0].TextEdits) != 1 {
+		return nil
+	}
+
+	edit := diag.SuggestedFixes[0].TextEdits[0]
+	start, end := fset.Position(edit.Pos), fset.Position(edit.End)
+	if !edit.End.IsValid() {
+		end = start
+	}
+	if start.Line != end.Line || start.Line != fset.Position(diag.Pos).Line {
+		return nil
+	}
+
+	return &types.Replacement{
+		Inline: &types.InlineReplacement{
+			StartCol:  start.Column - 1,
+			Length:    end.Offset - start.Offset,
+			NewString: string(edit.NewText),
+		},
+	}
+}
@@ -0,0 +1,37 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+func Test_analyzer_Execute(t *testing.T) {
+	dir := "testdata/analysis"
+	filenames, _, err := visitGoFiles(Context{Dir: dir}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := newAnalyzer(&types.LinterOption{Name: "ineffassign", Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, summaries, err := a.Execute(Context{Dir: dir, Filenames: filenames})
+	if err != nil {
+		t.Fatalf("analyzer.Execute() error = %v", err)
+	}
+	if p != 0.5 {
+		t.Errorf("analyzer.Execute() percentage = %v, want %v", p, 0.5)
+	}
+	if len(summaries) != 1 || summaries[0].Filename != "a.go" ||
+		len(summaries[0].Errors) != 1 || summaries[0].Errors[0].LineNumber != 6 {
+		t.Errorf("analyzer.Execute() summaries = %+v, want one error at a.go:6", summaries)
+		return
+	}
+	// category of diagnostic is not a severity, severity is the default of linter
+	if e := summaries[0].Errors[0]; e.Rule != "ineffassign" || e.Severity != "warning" {
+		t.Errorf("analyzer.Execute() rule = %q, severity = %q, want ineffassign with warning", e.Rule, e.Severity)
+	}
+}
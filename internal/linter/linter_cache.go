@@ -0,0 +1,481 @@
+package linter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yeqown/goreportcard/internal/repository"
+	"github.com/yeqown/goreportcard/internal/types"
+	"github.com/yeqown/log"
+)
+
+const (
+	// _lintCacheVersion should be increased if format of cached findings is changed
+	_lintCacheVersion = "2"
+	// _lintCacheTTL bounds the size of cache, findings of packages which are
+	// not linted again would expire.
+	_lintCacheTTL = 7 * 24 * time.Hour
+)
+
+// lintCache caches findings of linters per package in repository.IRepository.
+// A package is keyed by the content hash of its .go files and the hashes of
+// packages it imports in the module, go.mod and go.sum stand for external
+// dependencies. The dir of package is also hashed, since findings refer to
+// files by path. So a package would be linted again if itself or any of its
+// dependencies is changed or moved, or the executable of linter is changed,
+// see toolVersion. Entries expire after _lintCacheTTL.
+type lintCache struct {
+	repo   repository.IRepository
+	dir    string              // dir of module, findings are relative to it
+	tests  bool                // findings of test files or not
+	hashes map[string]string   // map[package dir]hash, dir is relative to dir of module
+	files  map[string][]string // map[package dir]filenames to lint
+
+	mu           sync.Mutex
+	hits, misses int               // count of (linter, package)
+	tools        map[string]string // map[linterName]version of executable, see toolVersion
+}
+
+// newLintCache hashes packages of ctx.Filenames, nil would be returned if no
+// cache is set in ctx.
+func newLintCache(ctx Context) (*lintCache, error) {
+	if ctx.Cache == nil {
+		return nil, nil
+	}
+
+	c := &lintCache{
+		repo:   ctx.Cache,
+		dir:    ctx.Dir,
+		tests:  ctx.tests,
+		hashes: make(map[string]string, 16),
+		files:  make(map[string][]string, 16),
+		tools:  make(map[string]string, 8),
+	}
+	for _, filename := range ctx.Filenames {
+		rel, err := filepath.Rel(ctx.Dir, filepath.Dir(filename))
+		if err != nil {
+			return nil, errors.Wrap(err, "newLintCache.Rel")
+		}
+		rel = filepath.ToSlash(rel)
+		c.files[rel] = append(c.files[rel], filename)
+	}
+
+	h := newPackageHasher(ctx.Dir)
+	for dir := range c.files {
+		hash, err := h.hash(dir)
+		if err != nil {
+			return nil, err
+		}
+		c.hashes[dir] = hash
+	}
+
+	return c, nil
+}
+
+// cacheable reports whether findings of linter only depend on code, native
+// linters which measure the whole repo are not cacheable.
+func cacheable(linter ILinter) bool {
+	switch linter.(type) {
+	case builtin, analyzer, gofmt, plugin:
+		return true
+	}
+	return false
+}
+
+// key of findings of linter on the package, linter config and version of
+// its executable are parts of key.
+func (c *lintCache) key(linter ILinter, dir string) []byte {
+	h := sha256.New()
+	h.Write([]byte(_lintCacheVersion + "\x00" + linter.Name() + "\x00" + strconv.FormatBool(c.tests) + "\x00"))
+	h.Write([]byte(c.toolVersion(linter) + "\x00"))
+	for _, opt := range types.GetConfig().Linters {
+		if opt.Name == linter.Name() {
+			data, _ := json.Marshal(opt)
+			h.Write(data)
+		}
+	}
+	h.Write([]byte("\x00" + c.hashes[dir]))
+
+	return []byte("lint-cache-" + hex.EncodeToString(h.Sum(nil)))
+}
+
+// toolVersion identifies the executable which linter runs: output of
+// `golangci-lint --version` for builtin linters, path, size and modification
+// time of command for plugins. So findings of an upgraded golangci-lint or a
+// rebuilt plugin are not served.
+func (c *lintCache) toolVersion(linter ILinter) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version, ok := c.tools[linter.Name()]; ok {
+		return version
+	}
+
+	var version string
+	switch l := linter.(type) {
+	case builtin:
+		version = golangciVersion()
+	case plugin:
+		version = executableVersion(l.command[0])
+	}
+	c.tools[linter.Name()] = version
+	return version
+}
+
+var (
+	_golangciVersionOnce sync.Once
+	_golangciVersion     string
+)
+
+// golangciVersion returns output of `golangci-lint --version`, it runs only
+// once per process.
+func golangciVersion() string {
+	_golangciVersionOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		out, err := exec.CommandContext(ctx, "golangci-lint", "--version").Output()
+		if err != nil {
+			log.Warnf("lintCache failed to get version of golangci-lint, err=%v", err)
+			return
+		}
+		_golangciVersion = strings.TrimSpace(string(out))
+	})
+	return _golangciVersion
+}
+
+// executableVersion returns path, size and modification time of command
+func executableVersion(command string) string {
+	path := command
+	if lookPath, err := exec.LookPath(command); err == nil {
+		path = lookPath
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return path
+	}
+	return path + "\x00" + strconv.FormatInt(fi.Size(), 10) + "\x00" + strconv.FormatInt(fi.ModTime().UnixNano(), 10)
+}
+
+// lookup returns cached findings of packages which are hit, and dirs of
+// packages which are missed.
+func (c *lintCache) lookup(ctx Context, linter ILinter) (hits []types.FileSummary, misses []string) {
+	for dir := range c.hashes {
+		data, err := c.repo.Get(c.key(linter, dir))
+		if err != nil {
+			if errors.Cause(err) != repository.ErrKeyNotFound {
+				log.Warnf("lintCache failed to get findings of linter=%s, package=%s, err=%v", linter.Name(), dir, err)
+			}
+			misses = append(misses, dir)
+			continue
+		}
+
+		var summaries []types.FileSummary
+		if err = json.Unmarshal(data, &summaries); err != nil {
+			log.Warnf("lintCache got invalid findings of linter=%s, package=%s, err=%v", linter.Name(), dir, err)
+			misses = append(misses, dir)
+			continue
+		}
+		for _, summary := range summaries {
+			// branch could be different
+			summary.FileURL = assembleRemoteFileURI(ctx.Dir, ctx.Branch, summary.Filename)
+			hits = append(hits, summary)
+		}
+	}
+
+	c.mu.Lock()
+	c.hits += len(c.hashes) - len(misses)
+	c.misses += len(misses)
+	c.mu.Unlock()
+
+	sort.Strings(misses)
+	return hits, misses
+}
+
+// store findings of linter on packages in dirs.
+func (c *lintCache) store(linter ILinter, dirs []string, summaries []types.FileSummary) {
+	byDir := make(map[string][]types.FileSummary, len(dirs))
+	for _, dir := range dirs {
+		byDir[dir] = []types.FileSummary{}
+	}
+	for _, summary := range summaries {
+		dir := path.Dir(filepath.ToSlash(summary.Filename))
+		if _, ok := byDir[dir]; ok {
+			byDir[dir] = append(byDir[dir], summary)
+		}
+	}
+
+	for dir, summaries := range byDir {
+		data, err := json.Marshal(summaries)
+		if err != nil {
+			log.Warnf("lintCache failed to marshal findings of linter=%s, err=%v", linter.Name(), err)
+			continue
+		}
+		if err = c.repo.UpdateWithTTL(c.key(linter, dir), data, _lintCacheTTL); err != nil {
+			log.Warnf("lintCache failed to store findings of linter=%s, package=%s, err=%v", linter.Name(), dir, err)
+		}
+	}
+}
+
+// filenames returns files of packages in dirs
+func (c *lintCache) filenames(dirs []string) []string {
+	filenames := make([]string, 0, len(dirs)*4)
+	for _, dir := range dirs {
+		filenames = append(filenames, c.files[dir]...)
+	}
+	sort.Strings(filenames)
+	return filenames
+}
+
+// stats returns count of hits and misses
+func (c *lintCache) stats() *types.CacheStats {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &types.CacheStats{Hits: c.hits, Misses: c.misses}
+}
+
+// cachedLinter runs linter only on packages which are missed in cache.
+type cachedLinter struct {
+	ILinter
+	cache  *lintCache
+	hits   []types.FileSummary // findings of packages which are hit
+	misses []string            // dirs of packages which are missed
+	scope  []string            // files to run linter, shared runners run on files of all linters
+}
+
+func (l cachedLinter) Execute(ctx Context) (float64, []types.FileSummary, error) {
+	summaries := append(make([]types.FileSummary, 0, len(l.hits)), l.hits...)
+	if len(l.misses) != 0 {
+		runCtx := ctx
+		runCtx.Filenames = l.scope
+		// percentage of part of files is useless, and scorer should not cache it
+		runCtx.scorer = nil
+		_, fresh, err := l.ILinter.Execute(runCtx)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		missed := make(map[string]struct{}, len(l.misses))
+		for _, dir := range l.misses {
+			missed[dir] = struct{}{}
+		}
+		kept := make([]types.FileSummary, 0, len(fresh))
+		for _, summary := range fresh {
+			if _, ok := missed[path.Dir(filepath.ToSlash(summary.Filename))]; ok {
+				kept = append(kept, summary)
+			}
+		}
+		l.cache.store(l.ILinter, l.misses, kept)
+		summaries = append(summaries, kept...)
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].Filename < summaries[j].Filename
+	})
+	p, err := calcPercentage(ctx, summaries)
+	return p, summaries, err
+}
+
+// withCache wraps cacheable linters into cachedLinter, and limits shared
+// runners of ctx to packages which are missed by any of their linters.
+func withCache(ctx Context, cache *lintCache, linters []ILinter) []ILinter {
+	var (
+		wrapped  = make([]cachedLinter, 0, len(linters))
+		golangci = make(map[string]struct{}, 8) // dirs missed by builtin linters
+		analysis = make(map[string]struct{}, 8) // dirs missed by analysis linters
+		out      = make([]ILinter, 0, len(linters))
+	)
+	for _, linter := range linters {
+		if !cacheable(linter) {
+			out = append(out, linter)
+			continue
+		}
+
+		hits, misses := cache.lookup(ctx, linter)
+		wrapped = append(wrapped, cachedLinter{ILinter: linter, cache: cache, hits: hits, misses: misses})
+		for _, dir := range misses {
+			switch linter.(type) {
+			case builtin:
+				golangci[dir] = struct{}{}
+			case analyzer:
+				analysis[dir] = struct{}{}
+			}
+		}
+	}
+
+	ctx.golangci.patterns = packagePatterns(golangci)
+	ctx.analysis.patterns = packagePatterns(analysis)
+	for _, l := range wrapped {
+		switch l.ILinter.(type) {
+		case builtin:
+			l.scope = cache.filenames(sortedKeys(golangci))
+		case analyzer:
+			l.scope = cache.filenames(sortedKeys(analysis))
+		default:
+			l.scope = cache.filenames(l.misses)
+		}
+		out = append(out, l)
+	}
+
+	return out
+}
+
+// mergeCache sums stats of cache, nil is returned if both are nil.
+func mergeCache(a, b *types.CacheStats) *types.CacheStats {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	return &types.CacheStats{Hits: a.Hits + b.Hits, Misses: a.Misses + b.Misses}
+}
+
+// packagePatterns converts dirs of packages into patterns of `go list`
+func packagePatterns(dirs map[string]struct{}) []string {
+	patterns := make([]string, 0, len(dirs))
+	for _, dir := range sortedKeys(dirs) {
+		patterns = append(patterns, "./"+dir)
+	}
+	return patterns
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// packageHasher hashes packages in dir of module recursively with packages
+// they import in the module.
+type packageHasher struct {
+	dir        string
+	modulePath string
+	external   string            // hash of go.mod and go.sum
+	hashes     map[string]string // map[package dir]hash
+}
+
+func newPackageHasher(dir string) *packageHasher {
+	h := &packageHasher{
+		dir:    dir,
+		hashes: make(map[string]string, 16),
+	}
+	h.modulePath, _ = readModulePath(dir)
+
+	sum := sha256.New()
+	for _, name := range []string{"go.mod", "go.sum"} {
+		data, _ := ioutil.ReadFile(filepath.Join(dir, name))
+		sum.Write(data)
+		sum.Write([]byte{0})
+	}
+	h.external = hex.EncodeToString(sum.Sum(nil))
+
+	return h
+}
+
+// hash of package in dir which is relative to dir of module
+func (h *packageHasher) hash(dir string) (string, error) {
+	if hash, ok := h.hashes[dir]; ok {
+		return hash, nil
+	}
+	// break import cycles, which are invalid anyway
+	h.hashes[dir] = ""
+
+	entries, err := ioutil.ReadDir(filepath.Join(h.dir, filepath.FromSlash(dir)))
+	if err != nil {
+		return "", errors.Wrap(err, "packageHasher.ReadDir")
+	}
+
+	var (
+		sum  = sha256.New()
+		deps = make(map[string]struct{}, 8)
+	)
+	sum.Write([]byte(h.external))
+	// findings refer to files by path, a moved package is not the same
+	sum.Write([]byte("\x00" + dir))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		filename := filepath.Join(h.dir, filepath.FromSlash(dir), entry.Name())
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return "", errors.Wrap(err, "packageHasher.ReadFile")
+		}
+		sum.Write([]byte("\x00" + entry.Name() + "\x00"))
+		sum.Write(src)
+
+		for _, dep := range h.imports(filename, src) {
+			deps[dep] = struct{}{}
+		}
+	}
+
+	sorted := make([]string, 0, len(deps))
+	for dep := range deps {
+		sorted = append(sorted, dep)
+	}
+	sort.Strings(sorted)
+	for _, dep := range sorted {
+		if dep == dir {
+			continue
+		}
+		depHash, err := h.hash(dep)
+		if err != nil {
+			// imported package is not in the module, such as vendored
+			continue
+		}
+		sum.Write([]byte("\x00" + dep + "\x00" + depHash))
+	}
+
+	hash := hex.EncodeToString(sum.Sum(nil))
+	h.hashes[dir] = hash
+	return hash, nil
+}
+
+// imports returns dirs of packages in the module which are imported by file
+func (h *packageHasher) imports(filename string, src []byte) []string {
+	if h.modulePath == "" {
+		return nil
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.ImportsOnly)
+	if err != nil {
+		return nil
+	}
+
+	dirs := make([]string, 0, len(f.Imports))
+	for _, spec := range f.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		switch {
+		case importPath == h.modulePath:
+			dirs = append(dirs, ".")
+		case strings.HasPrefix(importPath, h.modulePath+"/"):
+			dirs = append(dirs, strings.TrimPrefix(importPath, h.modulePath+"/"))
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,284 @@
+package linter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yeqown/goreportcard/internal/repository"
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+// memRepo is repository.IRepository in memory, ttl is not enforced
+type memRepo struct {
+	mu   sync.Mutex
+	kv   map[string][]byte
+	ttls map[string]time.Duration
+}
+
+func (m *memRepo) Get(key []byte) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.kv[string(key)]
+	if !ok {
+		return nil, repository.ErrKeyNotFound
+	}
+	return v, nil
+}
+
+func (m *memRepo) Update(key, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.kv[string(key)] = value
+	return nil
+}
+
+func (m *memRepo) UpdateWithTTL(key, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.kv[string(key)] = value
+	if m.ttls == nil {
+		m.ttls = make(map[string]time.Duration)
+	}
+	m.ttls[string(key)] = ttl
+	return nil
+}
+
+func (m *memRepo) Close() {}
+
+// writeModule writes files of a module into a temp dir
+func writeModule(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "lint-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+var _cacheModule = map[string]string{
+	"go.mod": "module example.com/m\n",
+	"a/a.go": "package a\n\nimport _ \"example.com/m/b\"\n",
+	"b/b.go": "package b\n",
+	"c/c.go": "package c\n\nimport _ \"fmt\"\n",
+}
+
+func Test_packageHasher_hash(t *testing.T) {
+	dir := writeModule(t, _cacheModule)
+	hashes := func() map[string]string {
+		h := newPackageHasher(dir)
+		m := make(map[string]string, 3)
+		for _, pkg := range []string{"a", "b", "c"} {
+			hash, err := h.hash(pkg)
+			if err != nil {
+				t.Fatalf("hash(%s) error = %v", pkg, err)
+			}
+			m[pkg] = hash
+		}
+		return m
+	}
+
+	before := hashes()
+	if err := ioutil.WriteFile(filepath.Join(dir, "b", "b.go"), []byte("package b\n\nvar X int\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	after := hashes()
+
+	tests := []struct {
+		name    string
+		pkg     string
+		changed bool
+	}{
+		{name: "case 1", pkg: "a", changed: true},  // imports b
+		{name: "case 2", pkg: "b", changed: true},  // changed itself
+		{name: "case 3", pkg: "c", changed: false}, // imports std only
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if changed := before[tt.pkg] != after[tt.pkg]; changed != tt.changed {
+				t.Errorf("hash(%s) changed = %v, want %v", tt.pkg, changed, tt.changed)
+			}
+		})
+	}
+}
+
+func Test_cachedLinter_Execute(t *testing.T) {
+	dir := writeModule(t, _cacheModule)
+	script, _ := filepath.Abs("testdata/cache/plugin.sh")
+	inputs := filepath.Join(dir, "inputs")
+	p, err := newPlugin(&types.LinterOption{Name: "banned", Command: []string{script, inputs}})
+	if err != nil {
+		t.Fatalf("newPlugin() error = %v", err)
+	}
+
+	repo := &memRepo{kv: make(map[string][]byte)}
+	run := func() ([]types.FileSummary, *types.CacheStats, string) {
+		ctx := Context{
+			Dir:       dir,
+			Branch:    types.MasterBranch,
+			Cache:     repo,
+			golangci:  newGolangciRunner(nil, false),
+			analysis:  newAnalysisRunner(nil, false),
+			Filenames: []string{filepath.Join(dir, "a", "a.go"), filepath.Join(dir, "b", "b.go"), filepath.Join(dir, "c", "c.go")},
+		}
+		_ = os.Remove(inputs)
+
+		cache, err := newLintCache(ctx)
+		if err != nil {
+			t.Fatalf("newLintCache() error = %v", err)
+		}
+		linters := withCache(ctx, cache, []ILinter{p})
+		_, summaries, err := linters[0].Execute(ctx)
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		input, _ := ioutil.ReadFile(inputs)
+		return summaries, cache.stats(), string(input)
+	}
+
+	tests := []struct {
+		name   string
+		change string // file to change before run
+		stats  types.CacheStats
+		linted []string // files passed to plugin
+	}{
+		{name: "case 1", stats: types.CacheStats{Hits: 0, Misses: 3}, linted: []string{"a/a.go", "b/b.go", "c/c.go"}},
+		{name: "case 2", stats: types.CacheStats{Hits: 3, Misses: 0}},
+		{name: "case 3", change: "b/b.go", stats: types.CacheStats{Hits: 1, Misses: 2}, linted: []string{"a/a.go", "b/b.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.change != "" {
+				filename := filepath.Join(dir, filepath.FromSlash(tt.change))
+				if err := ioutil.WriteFile(filename, []byte("package b\n\nvar Y int\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			summaries, stats, input := run()
+			if !reflect.DeepEqual(*stats, tt.stats) {
+				t.Errorf("stats = %+v, want %+v", *stats, tt.stats)
+			}
+			for _, file := range []string{"a/a.go", "b/b.go", "c/c.go"} {
+				want := false
+				for _, linted := range tt.linted {
+					want = want || linted == file
+				}
+				if got := strings.Contains(input, `"`+file+`"`); got != want {
+					t.Errorf("linted %s = %v, want %v, input = %s", file, got, want, input)
+				}
+			}
+			if len(summaries) != 1 || summaries[0].Filename != "a/a.go" || len(summaries[0].Errors) != 1 {
+				t.Errorf("summaries = %+v, want 1 issue in a/a.go", summaries)
+			}
+		})
+	}
+}
+
+func Test_lintCache_moved(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"go.mod":         "module example.com/m\n",
+		"pkg/old/x.go":   "package x\n",
+		"pkg/same/x.go":  "package x\n",
+		"pkg/other/y.go": "package y\n",
+	})
+	p, _ := newPlugin(&types.LinterOption{Name: "banned", Command: []string{"true"}})
+	repo := &memRepo{kv: make(map[string][]byte)}
+	lookup := func(dirs ...string) ([]types.FileSummary, []string) {
+		ctx := Context{Dir: dir, Branch: types.MasterBranch, Cache: repo}
+		for _, d := range dirs {
+			ctx.Filenames = append(ctx.Filenames, filepath.Join(dir, filepath.FromSlash(d), "x.go"))
+		}
+		cache, err := newLintCache(ctx)
+		if err != nil {
+			t.Fatalf("newLintCache() error = %v", err)
+		}
+		hits, misses := cache.lookup(ctx, p)
+		cache.store(p, misses, []types.FileSummary{{Filename: "pkg/old/x.go", Errors: []types.Error{{LineNumber: 1}}}})
+		return hits, misses
+	}
+
+	lookup("pkg/old")
+	if len(repo.ttls) == 0 {
+		t.Fatalf("findings are stored without ttl")
+	}
+	for key, ttl := range repo.ttls {
+		if ttl != _lintCacheTTL {
+			t.Errorf("ttl of %s = %v, want %v", key, ttl, _lintCacheTTL)
+		}
+	}
+	if err := os.Rename(filepath.Join(dir, "pkg", "old"), filepath.Join(dir, "pkg", "new")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		dir    string
+		misses []string
+	}{
+		{name: "case 1", dir: "pkg/new", misses: []string{"pkg/new"}},   // moved
+		{name: "case 2", dir: "pkg/same", misses: []string{"pkg/same"}}, // the same content
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, misses := lookup(tt.dir)
+			if len(hits) != 0 || !reflect.DeepEqual(misses, tt.misses) {
+				t.Errorf("lookup() = %+v, %v, want no hit and misses %v", hits, misses, tt.misses)
+			}
+		})
+	}
+}
+
+func Test_lintCache_toolVersion(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"go.mod":   "module example.com/m\n",
+		"a/a.go":   "package a\n",
+		"plugin":   "#!/bin/sh\necho '{}'\n",
+		"plugin.2": "#!/bin/sh\necho '{\"issues\":[]}'\n",
+	})
+	script := filepath.Join(dir, "plugin")
+	for _, name := range []string{"plugin", "plugin.2"} {
+		if err := os.Chmod(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, err := newPlugin(&types.LinterOption{Name: "banned", Command: []string{script}})
+	if err != nil {
+		t.Fatalf("newPlugin() error = %v", err)
+	}
+	key := func() string {
+		ctx := Context{Dir: dir, Cache: &memRepo{}, Filenames: []string{filepath.Join(dir, "a", "a.go")}}
+		cache, err := newLintCache(ctx)
+		if err != nil {
+			t.Fatalf("newLintCache() error = %v", err)
+		}
+		return string(cache.key(p, "a"))
+	}
+
+	before := key()
+	if key() != before {
+		t.Errorf("key of the same plugin should not change")
+	}
+	// rebuild the plugin
+	if err = os.Rename(filepath.Join(dir, "plugin.2"), script); err != nil {
+		t.Fatal(err)
+	}
+	if key() == before {
+		t.Errorf("key should change after the plugin is rebuilt")
+	}
+}
@@ -1,17 +1,21 @@
 package linter
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strconv"
+	"sort"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/yeqown/goreportcard/internal/types"
@@ -19,14 +23,19 @@ import (
 )
 
 var (
-	skipDirs       = []string{"Godeps", "vendor", "third_party", "testdata", "examples"}
-	skipSuffixes   = []string{".pb.go", ".pb.gw.go", ".generated.go", "bindata.go", "_string.go"}
-	skipFirstLines = []string{"code generated", "generated", "autogenerated", "@generated", "code autogenerated", "auto-generated"}
+	skipDirs     = []string{"Godeps", "vendor", "third_party", "testdata", "examples"}
+	skipSuffixes = []string{".pb.go", ".pb.gw.go", ".generated.go", "bindata.go", "_string.go"}
 )
 
-// GoFiles returns a slice of Go filenames
-// in a given directory.
-func visitGoFiles(dir string) (filenames []string, err error) {
+// visitGoFiles returns .go files in dir, and paths which are excluded by rules
+// of ctx.excluder or generated, paths are matched relative to root of repo.
+// Excluded dirs are not walked, so files in them are not listed.
+func visitGoFiles(ctx Context, dir string) (filenames []string, excluded []types.ExcludedPath, err error) {
+	root := ctx.root
+	if root == "" {
+		root = dir
+	}
+
 	visitFn := func(path string, fi os.FileInfo, err error) error {
 		if err != nil {
 			// can't walk here, but continue walking elsewhere
@@ -34,26 +43,28 @@ func visitGoFiles(dir string) (filenames []string, err error) {
 			return nil
 		}
 
+		rel, _ := filepath.Rel(root, path)
 		if fi.IsDir() {
-			return nil // not a file, ignore.
-		}
-
-		// if file in skip folder
-		for _, skip := range skipDirs {
-			if strings.Contains(path, fmt.Sprintf("/%s/", skip)) {
+			if path == dir {
 				return nil
 			}
-		}
-
-		filename := fi.Name()
-		for _, skip := range skipSuffixes {
-			if strings.HasSuffix(filename, skip) {
-				return nil
+			if rule := ctx.excluder.match(rel); rule != nil {
+				excluded = append(excluded, excludedPath(rel, true, rule))
+				return filepath.SkipDir
 			}
+			return nil
 		}
 
-		// not .go file or generated go files, ignore
-		if filepath.Ext(filename) != ".go" || isGenerated(path) {
+		// not .go file, ignore
+		if filepath.Ext(fi.Name()) != ".go" {
+			return nil
+		}
+		if rule := ctx.excluder.match(rel); rule != nil {
+			excluded = append(excluded, excludedPath(rel, false, rule))
+			return nil
+		}
+		if isGenerated(path) {
+			excluded = append(excluded, excludedPath(rel, false, &generatedRule))
 			return nil
 		}
 
@@ -62,49 +73,21 @@ func visitGoFiles(dir string) (filenames []string, err error) {
 	}
 
 	err = filepath.Walk(dir, visitFn)
-	return filenames, err
+	return filenames, excluded, err
 }
 
-// lineCount returns the number of lines in a given file
-func lineCount(filename string) (int, error) {
-	out, err := exec.Command("wc", "-l", filename).Output()
-	if err != nil {
-		return 0, err
-	}
-	// `wc -l` output is like: 999 filename.go
-	count, err := strconv.Atoi(strings.Split(strings.TrimSpace(string(out)), " ")[0])
-	if err != nil {
-		return 0, errors.Errorf("could not count lines: %v", err)
-	}
-
-	return count, nil
-}
-
-// determine whether the Go file was auto-generated
-// FIXME: fp is not right path to file
+// isGenerated reports whether the file is generated code by the convention of
+// https://go.dev/s/generatedcode: a comment line `// Code generated ... DO NOT EDIT.`
+// appears before the package clause, build tags and license headers may precede it.
 func isGenerated(fp string) bool {
-	file, err := os.Open(fp)
+	f, err := parser.ParseFile(token.NewFileSet(), fp, nil, parser.PackageClauseOnly|parser.ParseComments)
 	if err != nil {
 		fp, _ = filepath.Abs(fp)
-		log.Errorf("isGenerated failed to open file err=%v, fp=%s", err, fp)
+		log.Errorf("isGenerated failed to parse file err=%v, fp=%s", err, fp)
 		return false
 	}
-	defer file.Close()
-
-	// read first line of file and determine if it might
-	// be auto-generated
-	scanner := bufio.NewScanner(file)
-	scanner.Scan()
-	line := strings.ToLower(scanner.Text())
-	commentStyles := []string{"// ", "//", "/* ", "/*"}
-	for _, skip := range skipFirstLines {
-		for i := range commentStyles {
-			if strings.HasPrefix(line, commentStyles[i]) && strings.HasPrefix(line[len(commentStyles[i]):], skip) {
-				return true
-			}
-		}
-	}
-	return false
+
+	return ast.IsGenerated(f)
 }
 
 // assembleRemoteFileURI with repoDir, branchName and relativePathToFile
@@ -125,27 +108,28 @@ func assembleRemoteFileURI(dir, branch, fileRelativePath string) (URI string) {
 
 // issue as following format:
 //
-// {
-// 	"FromLinter": "deadcode",
-// 	"Text": "`findCaller` is unused",
-// 	"SourceLines": [
-// 		"func findCaller(skip int) (file, function string, line int) {"
-// 	],
-// 	"Replacement": null,
-// 	"Pos": {
-// 		"Filename": "caller.go",
-// 		"Offset": 52,
-// 		"Line": 8,
-// 		"Column": 6
-// 	},
-// 	"ExpectNoLint": false,
-// 	"ExpectedNoLintLinter": ""
-// }
-//
+//	{
+//		"FromLinter": "deadcode",
+//		"Text": "`findCaller` is unused",
+//		"SourceLines": [
+//			"func findCaller(skip int) (file, function string, line int) {"
+//		],
+//		"Replacement": null,
+//		"Pos": {
+//			"Filename": "caller.go",
+//			"Offset": 52,
+//			"Line": 8,
+//			"Column": 6
+//		},
+//		"ExpectNoLint": false,
+//		"ExpectedNoLintLinter": ""
+//	}
 type issue struct {
-	FromLinter  string   `json:"FromLinter"`
-	Text        string   `json:"Text"`
-	SourceLines []string `json:"SourceLines"`
+	FromLinter  string       `json:"FromLinter"`
+	Text        string       `json:"Text"`
+	Severity    string       `json:"Severity"`
+	SourceLines []string     `json:"SourceLines"`
+	Replacement *replacement `json:"Replacement"`
 	Pos         struct {
 		Filename string
 		Offset   int
@@ -154,70 +138,157 @@ type issue struct {
 	} `json:"Pos"`
 }
 
+type replacement struct {
+	NeedOnlyDelete bool
+	NewLines       []string
+	Inline         *struct {
+		StartCol  int
+		Length    int
+		NewString string
+	}
+}
+
+// toReplacement converts golangci-lint's replacement into types.Replacement
+func (r *replacement) toReplacement() *types.Replacement {
+	if r == nil {
+		return nil
+	}
+
+	out := &types.Replacement{
+		NeedOnlyDelete: r.NeedOnlyDelete,
+		NewLines:       r.NewLines,
+	}
+	if r.Inline != nil {
+		out.Inline = &types.InlineReplacement{
+			StartCol:  r.Inline.StartCol,
+			Length:    r.Inline.Length,
+			NewString: r.Inline.NewString,
+		}
+	}
+	return out
+}
+
 type golangciLintOutput struct {
 	Issues []issue
 }
 
-// parseGolangciLintInJSON parse json output into types.FileSummary
-func parseGolangciLintInJSON(ctx Context, data []byte) ([]types.FileSummary, error) {
+// parseGolangciLintInJSON parse json output into types.FileSummary,
+// and group them by the linter which reported issues. Generated files and
+// excluded paths are skipped by golangci-lint itself, see golangciSkipArgs.
+func parseGolangciLintInJSON(ctx Context, data []byte) (map[string][]types.FileSummary, error) {
 	output := new(golangciLintOutput)
 	if err := json.Unmarshal(data, output); err != nil {
 		return nil, errors.Wrap(err, "parseGolangciLintInJSON.jsonUnmarshal")
 	}
 
-	m := make(map[string]*types.FileSummary, 64)
+	m := make(map[string]*summaryCollector, 16) // map[linterName]collector
 	for _, issue := range output.Issues {
 		if !strings.HasSuffix(issue.Pos.Filename, ".go") {
 			// true: if not valid filename
 			continue
 		}
 
-		for _, skip := range skipSuffixes {
-			if strings.HasSuffix(issue.Pos.Filename, skip) {
-				// continue outer
-				break
-			}
-		}
-		if isGenerated(filepath.Join(ctx.Dir, issue.Pos.Filename)) {
-			continue
+		collector, ok := m[issue.FromLinter]
+		if !ok {
+			collector = newSummaryCollector(ctx)
+			m[issue.FromLinter] = collector
 		}
 
-		var (
-			summary *types.FileSummary
-			ok      bool
-		)
-		if summary, ok = m[issue.Pos.Filename]; !ok {
-			// summary of `filename` with error not exists, then initialize the one
-			summary = &types.FileSummary{
-				Filename: issue.Pos.Filename,
-				FileURL:  assembleRemoteFileURI(ctx.Dir, ctx.Branch, issue.Pos.Filename),
-			}
-		}
-
-		// NOTE: add more message to Error and show them out
-		summary.AddError(types.Error{
+		collector.add(issue.Pos.Filename, types.Error{
 			LineNumber:  issue.Pos.Line,
+			Column:      issue.Pos.Column,
+			Rule:        issue.FromLinter,
+			Severity:    defaultSeverity(issue.FromLinter, issue.Severity),
 			ErrorString: issue.Text,
+			SourceLines: issue.SourceLines,
+			Replacement: issue.Replacement.toReplacement(),
 		})
-		m[summary.Filename] = summary
 	}
 
-	summaries := make([]types.FileSummary, len(m))
-	for _, v := range m {
-		summaries = append(summaries, *v)
+	result := make(map[string][]types.FileSummary, len(m))
+	for linterName, collector := range m {
+		result[linterName] = collector.summaries()
 	}
 
-	return summaries, nil
+	return result, nil
 }
 
-// cmdHelper runs a given go command (for example gofmt, go tool vet)
-// on a directory
-func cmdHelper(ctx Context, command []string) (float64, []types.FileSummary, error) {
-	params := command[1:]
-	params = append(params, "./...")
+// summaryCollector collects errors into types.FileSummary by filename,
+// the same file can appear multiple times out of order.
+type summaryCollector struct {
+	ctx   Context
+	files map[string]*types.FileSummary // map[filename]summary
+	lines map[string][]string           // map[filename]lines, cache of source lines
+}
 
-	cmd := exec.Command(command[0], params...)
+func newSummaryCollector(ctx Context) *summaryCollector {
+	return &summaryCollector{
+		ctx:   ctx,
+		files: make(map[string]*types.FileSummary, 64),
+		lines: make(map[string][]string, 64),
+	}
+}
+
+// add an error of file, filename is relative path to ctx.Dir,
+// source line would be filled if err.SourceLines is empty.
+func (c *summaryCollector) add(filename string, err types.Error) {
+	if len(err.SourceLines) == 0 && err.LineNumber > 0 {
+		err.SourceLines = c.sourceLines(filename, err.LineNumber)
+	}
+
+	summary, ok := c.files[filename]
+	if !ok {
+		// summary of `filename` with error not exists, then initialize the one
+		summary = &types.FileSummary{
+			Filename: filename,
+			FileURL:  assembleRemoteFileURI(c.ctx.Dir, c.ctx.Branch, filename),
+		}
+		c.files[filename] = summary
+	}
+
+	summary.AddError(err)
+}
+
+// sourceLines returns the line of file, nil if file could not be read.
+func (c *summaryCollector) sourceLines(filename string, line int) []string {
+	lines, ok := c.lines[filename]
+	if !ok {
+		data, err := ioutil.ReadFile(filepath.Join(c.ctx.Dir, filename))
+		if err != nil {
+			log.Warnf("summaryCollector failed to read file=%s, err=%v", filename, err)
+		} else {
+			lines = strings.Split(string(data), "\n")
+		}
+		c.lines[filename] = lines
+	}
+
+	if line > len(lines) {
+		return nil
+	}
+	return []string{lines[line-1]}
+}
+
+// summaries returns collected summaries sorted by filename
+func (c *summaryCollector) summaries() []types.FileSummary {
+	summaries := make([]types.FileSummary, 0, len(c.files))
+	for _, v := range c.files {
+		summaries = append(summaries, *v)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Filename < summaries[j].Filename
+	})
+
+	return summaries
+}
+
+// cmdHelper runs golangci-lint on a directory, and returns issues
+// grouped by linter name.
+func cmdHelper(ctx Context, command []string) (map[string][]types.FileSummary, error) {
+	cmd := exec.CommandContext(ctx.stdContext(), command[0], command[1:]...)
 	cmd.Dir, _ = filepath.Abs(ctx.Dir)
+	killProcessGroup(cmd)
+	// pipes would be closed after killed, even if they are held by orphans
+	cmd.WaitDelay = time.Second
 	log.WithFields(log.Fields{
 		"command": cmd.String(),
 		"dir":     cmd.Dir,
@@ -226,42 +297,43 @@ func cmdHelper(ctx Context, command []string) (float64, []types.FileSummary, err
 	// create an pipe to receive stdout message
 	pipe, err := cmd.StdoutPipe()
 	if err != nil {
-		return 0, nil, errors.Wrap(err, "cmdHelper.cmd.StdoutPipe")
+		return nil, errors.Wrap(err, "cmdHelper.cmd.StdoutPipe")
 	}
 	defer pipe.Close()
 	cmd.Stderr = cmd.Stdout
 
 	if err = cmd.Start(); err != nil {
-		return 0, nil, errors.Wrap(err, "cmdHelper.cmd.Start")
+		return nil, errors.Wrap(err, "cmdHelper.cmd.Start")
 	}
 
 	// the same file can appear multiple times out of order
 	// in the output, so we can't go line by line, have to store
 	// a map of filename to FileSummary
-	summaries, err := scanAndWait(ctx, pipe, cmd)
+	issues, err := scanAndWait(ctx, pipe, cmd)
 	if err != nil {
 		log.Warnf("cmdHelper failed to scanAndWait, err=%v", err)
-		return 0, nil, err
+		return nil, err
 	}
 
 	log.WithFields(log.Fields{"cmd": cmd.String()}).Debug("one cmd finished")
-	// TRUE: sif only 1 file, so calc score = sum(error line) / sum(line)
-	if len(ctx.Filenames) == 1 {
-		lc, err := lineCount(ctx.Filenames[0])
-		if err != nil {
-			return 0, summaries, err
-		}
+	return issues, nil
+}
 
-		errCnt := 0
-		if len(summaries) != 0 {
-			errCnt = len(summaries[0].Errors)
-		}
+// calcPercentage calc the passing percentage of one linter with its summaries
+// by the scorer of ctx, file-ratio would be used if it's not set.
+func calcPercentage(ctx Context, summaries []types.FileSummary) (float64, error) {
+	if len(ctx.Filenames) == 0 {
+		// no file to grade, such as no file is changed in diff-aware linting
+		return 1, nil
+	}
 
-		return float64(lc-errCnt) / float64(lc), summaries, nil
+	scorer := ctx.scorer
+	if scorer == nil {
+		// not called by Lint
+		scorer = fileRatio{}
 	}
 
-	// ELSE: sum(no error file) / sum(file)
-	return float64(len(ctx.Filenames)-len(summaries)) / float64(len(ctx.Filenames)), summaries, nil
+	return scorer.Percentage(ctx, summaries)
 }
 
 // scanAndWait scan stdout and call `cmd.Wait`,
@@ -270,20 +342,21 @@ func cmdHelper(ctx Context, command []string) (float64, []types.FileSummary, err
 // 1. get all stdout
 // 2. judge cmd status, error to return
 // 3. else to parse error output
-func scanAndWait(ctx Context, r io.ReadCloser, cmd *exec.Cmd) ([]types.FileSummary, error) {
-	scanner := bufio.NewScanner(r)
+func scanAndWait(ctx Context, r io.ReadCloser, cmd *exec.Cmd) (map[string][]types.FileSummary, error) {
 	buf := bytes.NewBuffer(nil)
 
-	// 1. collect cache
-	for scanner.Scan() {
-		buf.Write(scanner.Bytes())
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, errors.Wrap(err, "cmdHelper.scanner.Err")
+	// 1. collect cache, json output is in one line which may be
+	// longer than bufio.Scanner's limit with all linters enabled.
+	if _, err := io.Copy(buf, r); err != nil {
+		return nil, errors.Wrap(err, "cmdHelper.io.Copy")
 	}
 
 	// 2. wait and judge command exit status
 	err := cmd.Wait()
+	if ctxErr := ctx.stdContext().Err(); ctxErr != nil {
+		// killed by canceled context
+		return nil, errors.Wrap(ctxErr, "cmdHelper.cmd.Wait")
+	}
 	if exitErr, ok := err.(*exec.ExitError); ok {
 		// The program has exited with an exit code != 0
 		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
@@ -299,11 +372,57 @@ func scanAndWait(ctx Context, r io.ReadCloser, cmd *exec.Cmd) ([]types.FileSumma
 
 parse:
 	// 3. command runs and quit normal, parse stdout errors
-	summaries, err := parseGolangciLintInJSON(ctx, buf.Bytes())
+	issues, err := parseGolangciLintInJSON(ctx, buf.Bytes())
 	if err != nil {
 		return nil, errors.Wrap(err, "cmdHelper.parseStdoutLines")
 	}
 
 	// FIXED: format invalid to return
-	return summaries, nil
+	return issues, nil
+}
+
+// settingInt reads an integer setting of linter, def would be returned if not set.
+func settingInt(settings map[string]interface{}, key string, def int) (int, error) {
+	v, ok := settings[key]
+	if !ok {
+		return def, nil
+	}
+
+	// toml decodes integers as int64
+	switch n := v.(type) {
+	case int64:
+		return int(n), nil
+	case int:
+		return n, nil
+	}
+
+	return 0, errors.Errorf("settings.%s should be integer, but got %v", key, v)
+}
+
+// settingBool reads a bool setting of linter, def would be returned if not set.
+func settingBool(settings map[string]interface{}, key string, def bool) (bool, error) {
+	v, ok := settings[key]
+	if !ok {
+		return def, nil
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, errors.Errorf("settings.%s should be bool, but got %v", key, v)
+	}
+	return b, nil
+}
+
+// settingString reads a string setting of linter, def would be returned if not set.
+func settingString(settings map[string]interface{}, key string, def string) (string, error) {
+	v, ok := settings[key]
+	if !ok {
+		return def, nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return "", errors.Errorf("settings.%s should be string, but got %v", key, v)
+	}
+	return s, nil
 }
@@ -1,8 +1,10 @@
 package linter
 
 import (
+	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/yeqown/goreportcard/internal/types"
 )
@@ -23,29 +25,138 @@ import (
 // 	}
 // }
 
-var goToolTests = []struct {
-	name      string
-	dir       string
-	filenames []string
-	tool      []string
-	percent   float64
-	failed    []types.FileSummary
-	wantErr   bool
-}{
-	{"go vet", "testfiles/", []string{"testfiles/a.go", "testfiles/b.go", "testfiles/c.go"}, []string{"go", "tool", "vet"}, 1, []types.FileSummary{}, false},
+func Test_isGenerated(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		want     bool
+	}{
+		{name: "case 0", filename: "testdata/generated/buildtag.go", want: true},
+		{name: "case 1", filename: "testdata/generated/license.go", want: true},
+		{name: "case 2", filename: "testdata/generated/handwritten.go", want: false},
+		{name: "case 3", filename: "testdata/generated/after.go", want: false},
+		{name: "case 4", filename: "testdata/generated/inline.go", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isGenerated(tt.filename); got != tt.want {
+				t.Errorf("isGenerated(%q) = %v, want %v", tt.filename, got, tt.want)
+			}
+		})
+	}
 }
 
-func TestGoTool(t *testing.T) {
-	for _, tt := range goToolTests {
-		f, fs, err := cmdHelper(tt.dir, tt.filenames, tt.tool)
-		if err != nil && !tt.wantErr {
-			t.Fatal(err)
-		}
-		if f != tt.percent {
-			t.Errorf("[%s] cmdHelper percent = %f, want %f", tt.name, f, tt.percent)
-		}
-		if !reflect.DeepEqual(fs, tt.failed) {
-			t.Errorf("[%s] cmdHelper failed = %v, want %v", tt.name, fs, tt.failed)
-		}
+func Test_parseGolangciLintInJSON(t *testing.T) {
+	data := []byte(`{"Issues":[
+{"FromLinter":"errcheck","Text":"Error return value is not checked","SourceLines":["\tf.Close()"],"Pos":{"Filename":"a.go","Line":3,"Column":2}},
+{"FromLinter":"govet","Text":"unreachable code","Severity":"warning","Pos":{"Filename":"b.go","Line":8,"Column":1}},
+{"FromLinter":"gosimple","Text":"should use for range instead of for { select {} }","SourceLines":["\tfor {"],"Replacement":{"NeedOnlyDelete":false,"NewLines":null,"Inline":{"StartCol":1,"Length":3,"NewString":"for range ch"}},"Pos":{"Filename":"b.go","Line":10,"Column":2}},
+{"FromLinter":"errcheck","Text":"Error return value is not checked","Pos":{"Filename":"a.go","Line":9,"Column":2}},
+{"FromLinter":"govet","Text":"not a go file","Pos":{"Filename":"go.mod","Line":1,"Column":1}}
+]}`)
+	ctx := Context{Dir: "testdata", Branch: types.MasterBranch}
+
+	got, err := parseGolangciLintInJSON(ctx, data)
+	if err != nil {
+		t.Fatalf("parseGolangciLintInJSON() error = %v", err)
+	}
+
+	want := map[string][]types.FileSummary{
+		"errcheck": {
+			{
+				Filename: "a.go",
+				FileURL:  assembleRemoteFileURI(ctx.Dir, ctx.Branch, "a.go"),
+				Errors: []types.Error{
+					{
+						LineNumber: 3, Column: 2, Rule: "errcheck", Severity: "error",
+						ErrorString: "Error return value is not checked",
+						SourceLines: []string{"\tf.Close()"},
+					},
+					{
+						LineNumber: 9, Column: 2, Rule: "errcheck", Severity: "error",
+						ErrorString: "Error return value is not checked",
+					},
+				},
+			},
+		},
+		"govet": {
+			{
+				Filename: "b.go",
+				FileURL:  assembleRemoteFileURI(ctx.Dir, ctx.Branch, "b.go"),
+				Errors: []types.Error{
+					{
+						LineNumber: 8, Column: 1, Rule: "govet", Severity: "warning",
+						ErrorString: "unreachable code",
+					},
+				},
+			},
+		},
+		"gosimple": {
+			{
+				Filename: "b.go",
+				FileURL:  assembleRemoteFileURI(ctx.Dir, ctx.Branch, "b.go"),
+				Errors: []types.Error{
+					{
+						LineNumber: 10, Column: 2, Rule: "gosimple", Severity: "warning",
+						ErrorString: "should use for range instead of for { select {} }",
+						SourceLines: []string{"\tfor {"},
+						Replacement: &types.Replacement{
+							Inline: &types.InlineReplacement{StartCol: 1, Length: 3, NewString: "for range ch"},
+						},
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseGolangciLintInJSON() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_calcPercentage(t *testing.T) {
+	tests := []struct {
+		name      string
+		filenames []string
+		summaries []types.FileSummary
+		want      float64
+	}{
+		{
+			name:      "case 0",
+			filenames: []string{"a.go", "b.go", "c.go", "d.go"},
+			summaries: nil,
+			want:      1,
+		},
+		{
+			name:      "case 1",
+			filenames: []string{"a.go", "b.go", "c.go", "d.go"},
+			summaries: []types.FileSummary{{Filename: "a.go"}},
+			want:      0.75,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := calcPercentage(Context{Filenames: tt.filenames}, tt.summaries)
+			if err != nil {
+				t.Fatalf("calcPercentage() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("calcPercentage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cmdHelper_cancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	// the child sleep holds stdout, it must be killed with the process group
+	_, err := cmdHelper(Context{Dir: ".", Ctx: ctx}, []string{"sh", "-c", "sleep 10 & sleep 10"})
+	if err == nil {
+		t.Fatalf("cmdHelper() want error")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("cmdHelper() returned after %s, want killed at deadline", elapsed)
 	}
 }
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package linter
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup starts cmd in a new process group, and kills the whole
+// group when the context of cmd is done, so that children of cmd (such as
+// linters spawned by golangci-lint or test binaries of `go test`) would
+// not be left running.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
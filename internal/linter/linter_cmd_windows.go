@@ -0,0 +1,15 @@
+//go:build windows
+
+package linter
+
+import (
+	"os/exec"
+)
+
+// killProcessGroup kills only the process of cmd when the context of cmd
+// is done, process group is not supported on windows.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		return cmd.Process.Kill()
+	}
+}
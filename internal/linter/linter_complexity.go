@@ -0,0 +1,401 @@
+package linter
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/yeqown/goreportcard/internal/types"
+	"github.com/yeqown/log"
+)
+
+var _ IDetailLinter = complexity{}
+
+// complexity computes cyclomatic and cognitive complexity of every function,
+// functions over thresholds would be reported. The distribution of the repo
+// is reported in types.Score.Complexity.
+//
+// cyclomatic: https://en.wikipedia.org/wiki/Cyclomatic_complexity
+// cognitive: https://www.sonarsource.com/docs/CognitiveComplexity.pdf
+type complexity struct {
+	name   string  // linter's name
+	desc   string  // linter's desc
+	weight float64 // linter's weight
+
+	cyclomatic int // threshold of cyclomatic complexity
+	cognitive  int // threshold of cognitive complexity
+}
+
+func newComplexity(opt *types.LinterOption) (ILinter, error) {
+	c := complexity{
+		name:   opt.Name,
+		desc:   opt.Desc,
+		weight: opt.Weight,
+	}
+
+	var err error
+	if c.cyclomatic, err = settingInt(opt.Settings, "cyclomatic", 15); err != nil {
+		return nil, errors.Wrap(err, "complexity")
+	}
+	if c.cognitive, err = settingInt(opt.Settings, "cognitive", 20); err != nil {
+		return nil, errors.Wrap(err, "complexity")
+	}
+
+	return c, nil
+}
+
+func (c complexity) Name() string {
+	return c.name
+}
+
+func (c complexity) Description() string {
+	return c.desc
+}
+
+func (c complexity) Weight() float64 {
+	return c.weight
+}
+
+func (c complexity) Execute(ctx Context) (float64, []types.FileSummary, error) {
+	score := types.Score{}
+	err := c.ExecuteDetail(ctx, &score)
+	return score.Percentage, score.Summaries, err
+}
+
+// funcComplexity is the complexity of one function
+type funcComplexity struct {
+	name       string
+	line       int
+	cyclomatic int
+	cognitive  int
+}
+
+func (c complexity) ExecuteDetail(ctx Context, score *types.Score) error {
+	var (
+		fset      = token.NewFileSet()
+		collector = newSummaryCollector(ctx)
+		funcs     = make([]funcComplexity, 0, 256)
+		over      int // count of functions over thresholds
+	)
+
+	for _, path := range ctx.Filenames {
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			// file could not be parsed, typecheck would report it
+			log.Warnf("complexity failed to parse file=%s, err=%v", path, err)
+			continue
+		}
+
+		filename, _ := filepath.Rel(ctx.Dir, path)
+		for _, fc := range fileComplexity(fset, f) {
+			funcs = append(funcs, fc)
+
+			var reported bool
+			if fc.cyclomatic > c.cyclomatic {
+				collector.add(filename, types.Error{
+					LineNumber: fc.line,
+					Rule:       "cyclomatic",
+					ErrorString: fmt.Sprintf("function %s has cyclomatic complexity %d (> %d)",
+						fc.name, fc.cyclomatic, c.cyclomatic),
+				})
+				reported = true
+			}
+			if fc.cognitive > c.cognitive {
+				collector.add(filename, types.Error{
+					LineNumber: fc.line,
+					Rule:       "cognitive",
+					ErrorString: fmt.Sprintf("function %s has cognitive complexity %d (> %d)",
+						fc.name, fc.cognitive, c.cognitive),
+				})
+				reported = true
+			}
+			if reported {
+				over++
+			}
+		}
+	}
+
+	score.Summaries = collector.summaries()
+	score.Percentage = 1
+	if len(funcs) != 0 {
+		score.Percentage = float64(len(funcs)-over) / float64(len(funcs))
+	}
+
+	cyclomatic := make([]float64, len(funcs))
+	cognitive := make([]float64, len(funcs))
+	for i, fc := range funcs {
+		cyclomatic[i] = float64(fc.cyclomatic)
+		cognitive[i] = float64(fc.cognitive)
+	}
+	score.Complexity = &types.ComplexityStats{
+		Functions:  len(funcs),
+		Cyclomatic: distribution(cyclomatic),
+		Cognitive:  distribution(cognitive),
+	}
+
+	return nil
+}
+
+// fileComplexity computes complexity of all functions declared in file
+func fileComplexity(fset *token.FileSet, f *ast.File) []funcComplexity {
+	funcs := make([]funcComplexity, 0, len(f.Decls))
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+
+		funcs = append(funcs, funcComplexity{
+			name:       funcName(fn),
+			line:       fset.Position(fn.Pos()).Line,
+			cyclomatic: cyclomaticComplexity(fn),
+			cognitive:  cognitiveComplexity(fn),
+		})
+	}
+
+	return funcs
+}
+
+// funcName returns name of function like: `Func`, `T.Method` or `(*T).Method`
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+
+	typ := fn.Recv.List[0].Type
+	if idx, ok := typ.(*ast.IndexExpr); ok {
+		// generic receiver
+		typ = idx.X
+	}
+	switch t := typ.(type) {
+	case *ast.StarExpr:
+		if ident, ok := t.X.(*ast.Ident); ok {
+			return "(*" + ident.Name + ")." + fn.Name.Name
+		}
+	case *ast.Ident:
+		return t.Name + "." + fn.Name.Name
+	}
+
+	return fn.Name.Name
+}
+
+// cyclomaticComplexity is 1 + the number of decision points:
+// if, for, range, case, comm clause, && and ||.
+func cyclomaticComplexity(fn *ast.FuncDecl) int {
+	complexity := 1
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			if n.List != nil {
+				// default clause is not a decision point
+				complexity++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				complexity++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				complexity++
+			}
+		}
+		return true
+	})
+
+	return complexity
+}
+
+// cognitiveComplexity computes cognitive complexity of function
+func cognitiveComplexity(fn *ast.FuncDecl) int {
+	v := &cognitiveVisitor{
+		name:       fn.Name,
+		elseIfs:    make(map[*ast.IfStmt]struct{}),
+		calculated: make(map[ast.Expr]struct{}),
+	}
+	if fn.Recv != nil && len(fn.Recv.List) != 0 && len(fn.Recv.List[0].Names) != 0 {
+		v.recv = fn.Recv.List[0].Names[0]
+	}
+
+	ast.Walk(v, fn.Body)
+	return v.complexity
+}
+
+// cognitiveVisitor walks function body, and increases complexity:
+//
+// 1. +1 for each break in the linear flow: if, else if, else, switch, select,
+// for, range, goto and break or continue with label, each sequence of like
+// binary logical operators and recursion.
+// 2. +nesting for each nested structure: if, switch, select, for and range.
+type cognitiveVisitor struct {
+	name       *ast.Ident // name of function
+	recv       *ast.Ident // name of receiver, if it's a method
+	complexity int
+	nesting    int
+
+	elseIfs    map[*ast.IfStmt]struct{} // `else if` does not increase by nesting
+	calculated map[ast.Expr]struct{}    // binary expressions have been calculated
+}
+
+func (v *cognitiveVisitor) Visit(n ast.Node) ast.Visitor {
+	switch n := n.(type) {
+	case *ast.IfStmt:
+		return v.visitIf(n)
+	case *ast.SwitchStmt:
+		v.complexity += 1 + v.nesting
+		v.walk(n.Init, n.Tag)
+		v.walkNested(n.Body)
+		return nil
+	case *ast.TypeSwitchStmt:
+		v.complexity += 1 + v.nesting
+		v.walk(n.Init, n.Assign)
+		v.walkNested(n.Body)
+		return nil
+	case *ast.SelectStmt:
+		v.complexity += 1 + v.nesting
+		v.walkNested(n.Body)
+		return nil
+	case *ast.ForStmt:
+		v.complexity += 1 + v.nesting
+		v.walk(n.Init, n.Cond, n.Post)
+		v.walkNested(n.Body)
+		return nil
+	case *ast.RangeStmt:
+		v.complexity += 1 + v.nesting
+		v.walk(n.Key, n.Value, n.X)
+		v.walkNested(n.Body)
+		return nil
+	case *ast.FuncLit:
+		v.walkNested(n.Body)
+		return nil
+	case *ast.BranchStmt:
+		if n.Label != nil {
+			// goto, and break or continue to label
+			v.complexity++
+		}
+	case *ast.BinaryExpr:
+		v.visitBinary(n)
+	case *ast.CallExpr:
+		if v.isRecursion(n) {
+			v.complexity++
+		}
+	}
+
+	return v
+}
+
+func (v *cognitiveVisitor) visitIf(n *ast.IfStmt) ast.Visitor {
+	if _, ok := v.elseIfs[n]; ok {
+		v.complexity++
+	} else {
+		v.complexity += 1 + v.nesting
+	}
+
+	v.walk(n.Init, n.Cond)
+	v.walkNested(n.Body)
+
+	switch els := n.Else.(type) {
+	case *ast.BlockStmt:
+		v.complexity++
+		v.walkNested(els)
+	case *ast.IfStmt:
+		v.elseIfs[els] = struct{}{}
+		ast.Walk(v, els)
+	}
+
+	return nil
+}
+
+// visitBinary increases complexity for each sequence of like logical operators,
+// for example: `a && b && c || d` is +2.
+func (v *cognitiveVisitor) visitBinary(n *ast.BinaryExpr) {
+	if _, ok := v.calculated[n]; ok || !isLogicalOp(n.Op) {
+		return
+	}
+
+	var last token.Token
+	for _, op := range v.logicalOps(n) {
+		if op != last {
+			v.complexity++
+			last = op
+		}
+	}
+}
+
+// logicalOps collects logical operators of binary expression in order.
+func (v *cognitiveVisitor) logicalOps(expr ast.Expr) []token.Token {
+	v.calculated[expr] = struct{}{}
+
+	switch e := expr.(type) {
+	case *ast.BinaryExpr:
+		if !isLogicalOp(e.Op) {
+			return nil
+		}
+		ops := v.logicalOps(e.X)
+		ops = append(ops, e.Op)
+		return append(ops, v.logicalOps(e.Y)...)
+	case *ast.ParenExpr:
+		return v.logicalOps(e.X)
+	}
+
+	return nil
+}
+
+func (v *cognitiveVisitor) isRecursion(call *ast.CallExpr) bool {
+	switch fn := call.Fun.(type) {
+	case *ast.Ident:
+		return v.recv == nil && fn.Name == v.name.Name
+	case *ast.SelectorExpr:
+		x, ok := fn.X.(*ast.Ident)
+		return ok && v.recv != nil && x.Name == v.recv.Name && fn.Sel.Name == v.name.Name
+	}
+
+	return false
+}
+
+func (v *cognitiveVisitor) walk(nodes ...ast.Node) {
+	for _, n := range nodes {
+		if n != nil {
+			ast.Walk(v, n)
+		}
+	}
+}
+
+func (v *cognitiveVisitor) walkNested(n ast.Node) {
+	v.nesting++
+	v.walk(n)
+	v.nesting--
+}
+
+func isLogicalOp(op token.Token) bool {
+	return op == token.LAND || op == token.LOR
+}
+
+// distribution calculates average, p90 and max of values
+func distribution(values []float64) types.Distribution {
+	if len(values) == 0 {
+		return types.Distribution{}
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+
+	// nearest-rank percentile
+	rank := (len(sorted)*90 + 99) / 100
+	return types.Distribution{
+		Average: sum / float64(len(sorted)),
+		P90:     sorted[rank-1],
+		Max:     sorted[len(sorted)-1],
+	}
+}
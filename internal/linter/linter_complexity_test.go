@@ -0,0 +1,95 @@
+package linter
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+const complexitySrc = `package a
+
+func SumOfPrimes(max int) int {
+	var total int
+OUT:
+	for i := 1; i < max; i++ {
+		for j := 2; j < i; j++ {
+			if i%j == 0 {
+				continue OUT
+			}
+		}
+		total += i
+	}
+	return total
+}
+
+func GetWords(number int) string {
+	switch number {
+	case 1:
+		return "one"
+	case 2:
+		return "a couple"
+	default:
+		return "lots"
+	}
+}
+
+func (t *T) Walk(n int, ok bool) {
+	if n > 0 && ok || n < -10 {
+		t.Walk(n-1, ok)
+	} else if n == 0 {
+		return
+	} else {
+		func() {
+			if ok {
+				println(n)
+			}
+		}()
+	}
+}
+`
+
+func Test_fileComplexity(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "a.go", complexitySrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []funcComplexity{
+		{name: "SumOfPrimes", line: 3, cyclomatic: 4, cognitive: 7},
+		{name: "GetWords", line: 17, cyclomatic: 3, cognitive: 1},
+		{name: "(*T).Walk", line: 28, cyclomatic: 6, cognitive: 9},
+	}
+	if got := fileComplexity(fset, f); !reflect.DeepEqual(got, want) {
+		t.Errorf("fileComplexity() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_distribution(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   types.Distribution
+	}{
+		{
+			name:   "case 0",
+			values: nil,
+			want:   types.Distribution{},
+		},
+		{
+			name:   "case 1",
+			values: []float64{10, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+			want:   types.Distribution{Average: 5.5, P90: 9, Max: 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := distribution(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("distribution() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
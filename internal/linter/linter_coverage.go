@@ -0,0 +1,398 @@
+package linter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yeqown/goreportcard/internal/types"
+	"github.com/yeqown/log"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/cover"
+)
+
+var _ ILinter = coverage{}
+
+// coverage runs `go test -coverprofile` in the repo, the percentage is the
+// statement coverage, and the least-covered files would be reported with
+// their uncovered line ranges.
+//
+// NOTE: it executes code of the repo, so it's not enabled by default. Tests
+// run with a minimal environment and an empty HOME, see testEnv, and under
+// hard resource limits set by prlimit, see limitArgs. The linter could not
+// be enabled if prlimit is not found.
+type coverage struct {
+	name   string  // linter's name
+	desc   string  // linter's desc
+	weight float64 // linter's weight
+
+	timeout     time.Duration // timeout of `go test`
+	parallel    int           // `go test -p`, number of packages to test in parallel
+	memoryLimit string        // GOMEMLIMIT and address space limit of each process
+	maxProcs    int           // limit of processes (and threads) of the user
+	maxFileSize string        // limit of size of files written by each process
+	files       int           // count of least-covered files to report
+
+	prlimit string // path of prlimit
+}
+
+func newCoverage(opt *types.LinterOption) (ILinter, error) {
+	c := coverage{
+		name:   opt.Name,
+		desc:   opt.Desc,
+		weight: opt.Weight,
+	}
+
+	timeout, err := settingString(opt.Settings, "timeout", "10m")
+	if err != nil {
+		return nil, errors.Wrap(err, "coverage")
+	}
+	if c.timeout, err = time.ParseDuration(timeout); err != nil {
+		return nil, errors.Wrap(err, "coverage: invalid settings.timeout")
+	}
+	if c.parallel, err = settingInt(opt.Settings, "parallel", 2); err != nil {
+		return nil, errors.Wrap(err, "coverage")
+	}
+	if c.memoryLimit, err = settingString(opt.Settings, "memory-limit", "1GiB"); err != nil {
+		return nil, errors.Wrap(err, "coverage")
+	}
+	if _, err = parseByteSize(c.memoryLimit); err != nil {
+		return nil, errors.Wrap(err, "coverage: invalid settings.memory-limit")
+	}
+	if c.maxProcs, err = settingInt(opt.Settings, "max-procs", 1024); err != nil {
+		return nil, errors.Wrap(err, "coverage")
+	}
+	if c.maxProcs <= 0 {
+		return nil, errors.Errorf("coverage: settings.max-procs should be positive, but got %d", c.maxProcs)
+	}
+	if c.maxFileSize, err = settingString(opt.Settings, "max-file-size", "256MiB"); err != nil {
+		return nil, errors.Wrap(err, "coverage")
+	}
+	if _, err = parseByteSize(c.maxFileSize); err != nil {
+		return nil, errors.Wrap(err, "coverage: invalid settings.max-file-size")
+	}
+	if c.files, err = settingInt(opt.Settings, "files", 10); err != nil {
+		return nil, errors.Wrap(err, "coverage")
+	}
+
+	// tests of repo must not run without hard limits
+	if c.prlimit, err = exec.LookPath("prlimit"); err != nil {
+		return nil, errors.Wrap(err, "coverage: prlimit is required to limit resources of tests")
+	}
+
+	return c, nil
+}
+
+func (c coverage) Name() string {
+	return c.name
+}
+
+func (c coverage) Description() string {
+	return c.desc
+}
+
+func (c coverage) Weight() float64 {
+	return c.weight
+}
+
+func (c coverage) Execute(ctx Context) (float64, []types.FileSummary, error) {
+	profiles, err := c.runTests(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	modulePath, err := readModulePath(ctx.Dir)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var (
+		total, covered int
+		files          = make([]fileCoverage, 0, len(profiles))
+	)
+	for _, profile := range profiles {
+		fc := newFileCoverage(profile)
+		total += fc.total
+		covered += fc.covered
+		if fc.covered == fc.total {
+			continue
+		}
+
+		fc.filename = strings.TrimPrefix(strings.TrimPrefix(profile.FileName, modulePath), "/")
+		files = append(files, fc)
+	}
+	if total == 0 {
+		return 0, nil, nil
+	}
+
+	// least-covered files first
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].percentage() < files[j].percentage()
+	})
+	if len(files) > c.files {
+		files = files[:c.files]
+	}
+
+	summaries := make([]types.FileSummary, 0, len(files))
+	for _, fc := range files {
+		summary := types.FileSummary{
+			Filename: fc.filename,
+			FileURL:  assembleRemoteFileURI(ctx.Dir, ctx.Branch, fc.filename),
+		}
+		for _, r := range fc.uncovered {
+			summary.AddError(types.Error{
+				LineNumber:  r.start,
+				Rule:        "uncovered",
+				ErrorString: fmt.Sprintf("lines %d-%d are not covered, file coverage %.1f%%", r.start, r.end, fc.percentage()*100),
+			})
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return float64(covered) / float64(total), summaries, nil
+}
+
+// runTests runs `go test` with coverprofile in a limited time and parallelism,
+// and parses the profile.
+func (c coverage) runTests(ctx Context) ([]*cover.Profile, error) {
+	fd, err := ioutil.TempFile("", "coverprofile-*.out")
+	if err != nil {
+		return nil, errors.Wrap(err, "coverage.TempFile")
+	}
+	fd.Close()
+	defer os.Remove(fd.Name())
+
+	// tests could not read credentials in HOME of the server, such as
+	// ~/.ssh, ~/.netrc and ~/.gitconfig.
+	home, err := ioutil.TempDir("", "coverage-home-*")
+	if err != nil {
+		return nil, errors.Wrap(err, "coverage.TempDir")
+	}
+	defer os.RemoveAll(home)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.stdContext(), c.timeout)
+	defer cancel()
+
+	args := append(c.limitArgs(),
+		"go", "test",
+		"-short",
+		"-p="+strconv.Itoa(c.parallel),
+		"-timeout="+c.timeout.String(),
+		"-covermode=set",
+		"-coverprofile="+fd.Name(),
+		"./...",
+	)
+	cmd := exec.CommandContext(timeoutCtx, c.prlimit, args...)
+	cmd.Dir, _ = filepath.Abs(ctx.Dir)
+	killProcessGroup(cmd)
+	cmd.Env = testEnv(os.Environ(), home, c.memoryLimit, c.parallel)
+	out := bytes.NewBuffer(nil)
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	log.WithFields(log.Fields{
+		"command": cmd.String(),
+		"dir":     cmd.Dir,
+	}).Debug("coverage got command")
+
+	runErr := cmd.Run()
+	if runErr != nil {
+		if timeoutCtx.Err() != nil {
+			return nil, errors.Wrapf(timeoutCtx.Err(), "coverage: go test timeout after %s", c.timeout)
+		}
+		// failed tests would not prevent writing profile of other packages
+		log.Warnf("coverage: go test failed, err=%v, output=%s", runErr, out.String())
+	}
+
+	profiles, err := cover.ParseProfiles(fd.Name())
+	if err != nil {
+		return nil, errors.Wrap(err, "coverage.ParseProfiles")
+	}
+	if len(profiles) == 0 && runErr != nil {
+		return nil, errors.Errorf("coverage: go test failed: %s", out.String())
+	}
+
+	return profiles, nil
+}
+
+// limitArgs are arguments of prlimit which set hard limits of `go test`,
+// the go command and test binaries inherit them:
+//
+//	--as     address space of each process, memoryLimit
+//	--cpu    CPU time of each process, timeout * parallel
+//	--nproc  processes and threads of the user, maxProcs, it's ignored for root
+//	--fsize  size of files written by each process, maxFileSize
+func (c coverage) limitArgs() []string {
+	memoryLimit, _ := parseByteSize(c.memoryLimit)
+	maxFileSize, _ := parseByteSize(c.maxFileSize)
+	cpu := int64(c.timeout.Seconds()) * int64(c.parallel)
+	if cpu < 1 {
+		cpu = 1
+	}
+
+	return []string{
+		"--as=" + strconv.FormatInt(memoryLimit, 10),
+		"--cpu=" + strconv.FormatInt(cpu, 10),
+		"--nproc=" + strconv.Itoa(c.maxProcs),
+		"--fsize=" + strconv.FormatInt(maxFileSize, 10),
+		"--",
+	}
+}
+
+// _byteUnits are units of GOMEMLIMIT
+var _byteUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"TiB", 1 << 40},
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+	{"B", 1},
+}
+
+// parseByteSize parses size in the format of GOMEMLIMIT, such as "512MiB".
+func parseByteSize(s string) (int64, error) {
+	num, unit := s, int64(1)
+	for _, u := range _byteUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			num, unit = strings.TrimSuffix(s, u.suffix), u.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "parseByteSize")
+	}
+	if n <= 0 || n > math.MaxInt64/unit {
+		return 0, errors.Errorf("parseByteSize: size out of range: %s", s)
+	}
+	return n * unit, nil
+}
+
+// _testEnvKeys are variables of environ passed to tests of repo, the others,
+// such as secrets and credentials of VCS, are not passed. HOME is replaced
+// by an empty directory.
+var _testEnvKeys = map[string]struct{}{
+	"PATH":       {},
+	"TMPDIR":     {},
+	"GOROOT":     {},
+	"GOPATH":     {},
+	"GOCACHE":    {},
+	"GOMODCACHE": {},
+	"GOFLAGS":    {},
+}
+
+// testEnv is the environment of `go test` from environ, only _testEnvKeys
+// are kept. GOPATH, GOCACHE and GOMODCACHE default to directories in HOME,
+// so they are set to the defaults of the server if not in environ, and
+// tests share the caches of the server rather than an empty one.
+func testEnv(environ []string, home, memoryLimit string, parallel int) []string {
+	env := make([]string, 0, len(_testEnvKeys)+3)
+	kept := make(map[string]struct{}, len(_testEnvKeys))
+	for _, kv := range environ {
+		key := strings.SplitN(kv, "=", 2)[0]
+		if _, ok := _testEnvKeys[key]; ok {
+			env = append(env, kv)
+			kept[key] = struct{}{}
+		}
+	}
+
+	for _, kv := range goDirs() {
+		key := strings.SplitN(kv, "=", 2)[0]
+		if _, ok := kept[key]; !ok {
+			env = append(env, kv)
+		}
+	}
+
+	return append(env,
+		"HOME="+home,
+		"GOMEMLIMIT="+memoryLimit,
+		"GOMAXPROCS="+strconv.Itoa(parallel),
+	)
+}
+
+// goDirs returns default GOPATH, GOCACHE and GOMODCACHE of the go command,
+// the ones could not be resolved are omitted.
+func goDirs() []string {
+	dirs := make([]string, 0, 3)
+	gopath := filepath.SplitList(build.Default.GOPATH)
+	if len(gopath) != 0 && gopath[0] != "" {
+		dirs = append(dirs,
+			"GOPATH="+build.Default.GOPATH,
+			"GOMODCACHE="+filepath.Join(gopath[0], "pkg", "mod"),
+		)
+	}
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		dirs = append(dirs, "GOCACHE="+filepath.Join(cacheDir, "go-build"))
+	}
+
+	return dirs
+}
+
+// lineRange is a range of lines [start, end]
+type lineRange struct {
+	start, end int
+}
+
+// fileCoverage is statement coverage of one file
+type fileCoverage struct {
+	filename       string
+	total, covered int         // count of statements
+	uncovered      []lineRange // merged uncovered lines
+}
+
+func newFileCoverage(profile *cover.Profile) fileCoverage {
+	fc := fileCoverage{}
+	for _, block := range profile.Blocks {
+		fc.total += block.NumStmt
+		if block.Count > 0 {
+			fc.covered += block.NumStmt
+			continue
+		}
+
+		// merge adjacent uncovered blocks, blocks are sorted by position
+		if n := len(fc.uncovered); n != 0 && block.StartLine <= fc.uncovered[n-1].end+1 {
+			if block.EndLine > fc.uncovered[n-1].end {
+				fc.uncovered[n-1].end = block.EndLine
+			}
+			continue
+		}
+		fc.uncovered = append(fc.uncovered, lineRange{start: block.StartLine, end: block.EndLine})
+	}
+
+	return fc
+}
+
+func (fc fileCoverage) percentage() float64 {
+	if fc.total == 0 {
+		return 1
+	}
+	return float64(fc.covered) / float64(fc.total)
+}
+
+// readModulePath reads module path from go.mod in dir
+func readModulePath(dir string) (string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", errors.Wrap(err, "readModulePath.ReadFile")
+	}
+
+	modulePath := modfile.ModulePath(data)
+	if modulePath == "" {
+		return "", errors.New("readModulePath: no module path in go.mod")
+	}
+	return modulePath, nil
+}
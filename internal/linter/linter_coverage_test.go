@@ -0,0 +1,121 @@
+package linter
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/tools/cover"
+)
+
+func Test_newFileCoverage(t *testing.T) {
+	profile := &cover.Profile{
+		FileName: "github.com/yeqown/goreportcard/internal/a.go",
+		Mode:     "set",
+		Blocks: []cover.ProfileBlock{
+			{StartLine: 3, EndLine: 5, NumStmt: 2, Count: 1},
+			{StartLine: 6, EndLine: 8, NumStmt: 2, Count: 0},
+			{StartLine: 8, EndLine: 10, NumStmt: 1, Count: 0},
+			{StartLine: 12, EndLine: 14, NumStmt: 3, Count: 1},
+			{StartLine: 16, EndLine: 16, NumStmt: 2, Count: 0},
+		},
+	}
+
+	want := fileCoverage{
+		total:     10,
+		covered:   5,
+		uncovered: []lineRange{{start: 6, end: 10}, {start: 16, end: 16}},
+	}
+	got := newFileCoverage(profile)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("newFileCoverage() = %+v, want %+v", got, want)
+	}
+	if got.percentage() != 0.5 {
+		t.Errorf("fileCoverage.percentage() = %v, want %v", got.percentage(), 0.5)
+	}
+}
+
+func Test_testEnv(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		"HOME=/home/app",
+		"GOPATH=/go",
+		"GOCACHE=/cache/go-build",
+		"GOMODCACHE=/go/pkg/mod",
+		"GOFLAGS=-mod=mod",
+		"AWS_SECRET_ACCESS_KEY=secret",
+		"GIT_ASKPASS=/usr/bin/askpass",
+		"PATHEXT=.exe",
+	}
+	want := []string{
+		"PATH=/usr/bin", "GOPATH=/go", "GOCACHE=/cache/go-build", "GOMODCACHE=/go/pkg/mod", "GOFLAGS=-mod=mod",
+		"HOME=/tmp/home", "GOMEMLIMIT=1GiB", "GOMAXPROCS=2",
+	}
+	if got := testEnv(environ, "/tmp/home", "1GiB", 2); !reflect.DeepEqual(got, want) {
+		t.Errorf("testEnv() = %v, want %v", got, want)
+	}
+}
+
+func Test_parseByteSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    int64
+		wantErr bool
+	}{
+		{name: "case 0", s: "1GiB", want: 1 << 30},
+		{name: "case 1", s: "512MiB", want: 512 << 20},
+		{name: "case 2", s: "100", want: 100},
+		{name: "case 3", s: "64B", want: 64},
+		{name: "case 4", s: "1GB", wantErr: true},
+		{name: "case 5", s: "0MiB", wantErr: true},
+		{name: "case 6", s: "-1KiB", wantErr: true},
+		{name: "case 7", s: "9999999TiB", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseByteSize(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseByteSize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseByteSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_coverage_limitArgs(t *testing.T) {
+	c := coverage{
+		timeout:     10 * time.Minute,
+		parallel:    2,
+		memoryLimit: "1GiB",
+		maxProcs:    1024,
+		maxFileSize: "256MiB",
+	}
+	want := []string{"--as=1073741824", "--cpu=1200", "--nproc=1024", "--fsize=268435456", "--"}
+	if got := c.limitArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("coverage.limitArgs() = %v, want %v", got, want)
+	}
+}
+
+func Test_coverage_limitArgs_enforced(t *testing.T) {
+	prlimit, err := exec.LookPath("prlimit")
+	if err != nil {
+		t.Skip("prlimit is not found")
+	}
+
+	c := coverage{timeout: time.Minute, parallel: 1, memoryLimit: "1GiB", maxProcs: 1024, maxFileSize: "1KiB"}
+	filename := filepath.Join(t.TempDir(), "big")
+	args := append(c.limitArgs(), "sh", "-c", "head -c 4096 /dev/zero > "+filename)
+	if err = exec.Command(prlimit, args...).Run(); err == nil {
+		t.Errorf("writing a file larger than max-file-size should fail")
+	}
+	if fi, err := os.Stat(filename); err != nil || fi.Size() > 1024 {
+		t.Errorf("file should be truncated to max-file-size, stat=%v, err=%v", fi, err)
+	}
+}
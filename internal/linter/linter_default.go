@@ -1,13 +1,28 @@
 package linter
 
-import "github.com/yeqown/goreportcard/internal/types"
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yeqown/goreportcard/internal/types"
+)
 
 var _ ILinter = &builtin{}
 
+// builtin is a linter provided by golangci-lint. All builtin linters of one
+// Lint call share a single golangci-lint process, see golangciRunner.
 type builtin struct {
 	name   string  // linter's name
 	desc   string  // linter's desc
 	weight float64 // linter's weight
+
+	settings map[string]interface{} // linters-settings of golangci-lint
 }
 
 func (b builtin) Name() string {
@@ -23,16 +38,120 @@ func (b builtin) Weight() float64 {
 }
 
 func (b builtin) Execute(ctx Context) (float64, []types.FileSummary, error) {
-	command := []string{
-		"golangci-lint", "run",
-		"--out-format=json",
-		"--deadline=180s",
-		"--disable-all",
-		"--enable=" + b.name,
-		"--allow-parallel-runners",
-		"--skip-dirs-use-default=true",
-		"--tests=false",
-	}
-
-	return cmdHelper(ctx, command)
+	runner := ctx.golangci
+	if runner == nil {
+		// not called by Lint, so run golangci-lint only for this linter
+		runner = newGolangciRunner([]builtin{b}, ctx.tests)
+	}
+
+	issues, err := runner.run(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	summaries := issues[b.name]
+	p, err := calcPercentage(ctx, summaries)
+	return p, summaries, err
+}
+
+// golangciRunner runs golangci-lint only once with all builtin linters enabled,
+// so the repo would be loaded and type-checked only once. Issues are split by
+// the linter which reported them.
+type golangciRunner struct {
+	once     sync.Once
+	linters  []string                          // names of enabled linters
+	tests    bool                              // lint test files or not
+	settings map[string]map[string]interface{} // map[linterName]settings
+	patterns []string                          // packages to lint, all packages if empty
+
+	issues map[string][]types.FileSummary // map[linterName]summaries
+	err    error
+}
+
+func newGolangciRunner(builtins []builtin, tests bool) *golangciRunner {
+	r := &golangciRunner{
+		tests:    tests,
+		linters:  make([]string, 0, len(builtins)),
+		settings: make(map[string]map[string]interface{}, len(builtins)),
+	}
+	for _, b := range builtins {
+		r.linters = append(r.linters, b.name)
+		if len(b.settings) != 0 {
+			r.settings[b.name] = b.settings
+		}
+	}
+
+	return r
+}
+
+// run executes golangci-lint at the first call, and the others
+// wait and share the same result.
+func (r *golangciRunner) run(ctx Context) (map[string][]types.FileSummary, error) {
+	r.once.Do(func() {
+		command := []string{
+			"golangci-lint", "run",
+			"--out-format=json",
+			golangciTimeout(ctx.shared()),
+			"--disable-all",
+			"--enable=" + strings.Join(r.linters, ","),
+			"--allow-parallel-runners",
+			"--skip-dirs-use-default=true",
+			"--tests=" + strconv.FormatBool(r.tests),
+		}
+		command = append(command, golangciSkipArgs(ctx)...)
+
+		if len(r.settings) != 0 {
+			confPath, err := writeGolangciConfig(r.settings)
+			if err != nil {
+				r.err = err
+				return
+			}
+			defer os.Remove(confPath)
+			command = append(command, "--config="+confPath)
+		}
+
+		if len(r.patterns) == 0 {
+			command = append(command, "./...")
+		}
+		command = append(command, r.patterns...)
+		r.issues, r.err = cmdHelper(ctx.shared(), command)
+	})
+
+	return r.issues, r.err
+}
+
+// golangciTimeout is `--timeout` of golangci-lint, it's the time left before
+// the deadline of ctx, so golangci-lint stops before it's killed.
+func golangciTimeout(ctx Context) string {
+	timeout := types.GetConfig().ReportTimeout()
+	if deadline, ok := ctx.stdContext().Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	if timeout < time.Second {
+		timeout = time.Second
+	}
+	return "--timeout=" + timeout.Round(time.Second).String()
+}
+
+// writeGolangciConfig writes linters' settings into a temporary golangci-lint
+// config file in JSON format, and returns the path to it.
+func writeGolangciConfig(settings map[string]map[string]interface{}) (string, error) {
+	data, err := json.Marshal(map[string]interface{}{
+		"linters-settings": settings,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "writeGolangciConfig.jsonMarshal")
+	}
+
+	fd, err := ioutil.TempFile("", "golangci-*.json")
+	if err != nil {
+		return "", errors.Wrap(err, "writeGolangciConfig.TempFile")
+	}
+	defer fd.Close()
+
+	if _, err = fd.Write(data); err != nil {
+		return "", errors.Wrap(err, "writeGolangciConfig.Write")
+	}
+
+	return fd.Name(), nil
 }
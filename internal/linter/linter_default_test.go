@@ -0,0 +1,32 @@
+package linter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_golangciTimeout(t *testing.T) {
+	withDeadline := func(d time.Duration) context.Context {
+		ctx, cancel := context.WithTimeout(context.Background(), d)
+		t.Cleanup(cancel)
+		return ctx
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "case 1", ctx: nil, want: "--timeout=20m0s"},
+		{name: "case 2", ctx: withDeadline(90 * time.Second), want: "--timeout=1m30s"},
+		{name: "case 3", ctx: withDeadline(-time.Second), want: "--timeout=1s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := golangciTimeout(Context{Ctx: tt.ctx}); got != tt.want {
+				t.Errorf("golangciTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,508 @@
+package linter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yeqown/goreportcard/internal/types"
+	"github.com/yeqown/log"
+	"golang.org/x/mod/modfile"
+	gomodule "golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+var _ IDetailLinter = deps{}
+
+const (
+	// _maxMajorProbes limits how many newer major versions would be probed.
+	_maxMajorProbes = 10
+	// _depsWorkers is count of dependencies checked against proxy in parallel.
+	_depsWorkers = 8
+	// _majorCacheTTL is how long newer major versions of a module are cached,
+	// they are probed by many requests of proxy but rarely change.
+	_majorCacheTTL = time.Hour
+	// _majorCacheSize bounds count of modules in _majorCache.
+	_majorCacheSize = 4096
+)
+
+// _majorCache caches results of deps.newerMajor across runs
+var _majorCache = &majorCache{entries: make(map[string]majorEntry, 64)}
+
+// errNotInProxy means the module or version is not found in GOPROXY.
+var errNotInProxy = errors.New("not found in proxy")
+
+// deps parses go.mod and go.sum of the module, and checks required modules
+// against GOPROXY: retracted and deprecated versions, newer major versions,
+// and replace directives which point at local paths. The percentage is
+// the ratio of dependencies without findings.
+type deps struct {
+	name   string  // linter's name
+	desc   string  // linter's desc
+	weight float64 // linter's weight
+
+	proxy string // base URL of GOPROXY, proxy is not queried if empty
+}
+
+func newDeps(opt *types.LinterOption) (ILinter, error) {
+	d := deps{
+		name:   opt.Name,
+		desc:   opt.Desc,
+		weight: opt.Weight,
+	}
+
+	def := os.Getenv("GOPROXY")
+	if def == "" {
+		def = "https://proxy.golang.org"
+	}
+	proxies, err := settingString(opt.Settings, "goproxy", def)
+	if err != nil {
+		return nil, errors.Wrap(err, "deps")
+	}
+	if d.proxy, err = firstProxy(proxies); err != nil {
+		return nil, errors.Wrap(err, "deps")
+	}
+
+	return d, nil
+}
+
+// firstProxy returns the first proxy of GOPROXY list, "direct" and "off"
+// mean no proxy.
+func firstProxy(proxies string) (string, error) {
+	for _, proxy := range strings.FieldsFunc(proxies, func(r rune) bool { return r == ',' || r == '|' }) {
+		proxy = strings.TrimSpace(proxy)
+		switch proxy {
+		case "direct", "off":
+			return "", nil
+		case "":
+			continue
+		}
+
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return "", errors.Errorf("invalid settings.goproxy %q", proxy)
+		}
+		switch u.Scheme {
+		case "http", "https", "file":
+		default:
+			return "", errors.Errorf("invalid settings.goproxy %q, scheme should be http, https or file", proxy)
+		}
+		return strings.TrimSuffix(proxy, "/"), nil
+	}
+
+	return "", nil
+}
+
+func (d deps) Name() string {
+	return d.name
+}
+
+func (d deps) Description() string {
+	return d.desc
+}
+
+func (d deps) Weight() float64 {
+	return d.weight
+}
+
+func (d deps) Execute(ctx Context) (float64, []types.FileSummary, error) {
+	score := types.Score{}
+	err := d.ExecuteDetail(ctx, &score)
+	return score.Percentage, score.Summaries, err
+}
+
+func (d deps) ExecuteDetail(ctx Context, score *types.Score) error {
+	data, err := ioutil.ReadFile(filepath.Join(ctx.Dir, "go.mod"))
+	if os.IsNotExist(err) {
+		// not module aware, nothing to check
+		score.Percentage = 1
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "deps.ReadFile")
+	}
+	f, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		return errors.Wrap(err, "deps.Parse")
+	}
+	sums := readGoSum(filepath.Join(ctx.Dir, "go.sum"))
+
+	var (
+		collector = newSummaryCollector(ctx)
+		unhealthy = 0
+		list      = make([]types.Dependency, 0, len(f.Require))
+		local     = make(map[string]bool, len(f.Replace)) // modules replaced by local paths
+	)
+	for _, r := range f.Replace {
+		if r.New.Version != "" {
+			continue
+		}
+		collector.add("go.mod", types.Error{
+			LineNumber:  r.Syntax.Start.Line,
+			Rule:        "local-replace",
+			Severity:    "warning",
+			ErrorString: fmt.Sprintf("%s is replaced by local path %s, which is not available to others", r.Old.Path, r.New.Path),
+		})
+		local[r.Old.Path] = true
+	}
+
+	for _, req := range f.Require {
+		dep := types.Dependency{
+			Path:     req.Mod.Path,
+			Version:  req.Mod.Version,
+			Indirect: req.Indirect,
+			Sum:      sums[req.Mod.Path+" "+req.Mod.Version],
+		}
+		for _, r := range f.Replace {
+			if r.Old.Path == req.Mod.Path && (r.Old.Version == "" || r.Old.Version == req.Mod.Version) {
+				dep.Replace = r.New.Path
+				if r.New.Version != "" {
+					dep.Replace += "@" + r.New.Version
+				}
+			}
+		}
+		list = append(list, dep)
+	}
+	d.checkAll(ctx.stdContext(), list, local)
+
+	for i, req := range f.Require {
+		dep := list[i]
+		if local[req.Mod.Path] {
+			// code of the module is not from proxy
+			unhealthy++
+			continue
+		}
+
+		findings := 0
+		report := func(rule, severity, format string, args ...interface{}) {
+			findings++
+			collector.add("go.mod", types.Error{
+				LineNumber:  req.Syntax.Start.Line,
+				Rule:        rule,
+				Severity:    severity,
+				ErrorString: fmt.Sprintf(format, args...),
+			})
+		}
+		if dep.Retracted != "" {
+			report("retracted", "error", "%s@%s is retracted: %s", dep.Path, dep.Version, dep.Retracted)
+		}
+		if dep.Deprecated != "" {
+			report("deprecated", "warning", "%s is deprecated: %s", dep.Path, dep.Deprecated)
+		}
+		if dep.Major != "" {
+			report("major-drift", "info", "%s is required, but the newer major version %s is available", dep.Path, dep.Major)
+		}
+
+		if findings != 0 {
+			unhealthy++
+		}
+	}
+
+	score.Summaries = collector.summaries()
+	score.Dependencies = list
+	score.Percentage = 1
+	if len(list) != 0 {
+		score.Percentage = float64(len(list)-unhealthy) / float64(len(list))
+	}
+	return nil
+}
+
+// checkAll checks dependencies in list against proxy by _depsWorkers in
+// parallel, modules replaced by local paths are skipped.
+func (d deps) checkAll(ctx context.Context, list []types.Dependency, local map[string]bool) {
+	if d.proxy == "" {
+		return
+	}
+
+	var (
+		wg    sync.WaitGroup
+		queue = make(chan *types.Dependency)
+	)
+	for i := 0; i < _depsWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for dep := range queue {
+				if err := d.check(ctx, dep); err != nil {
+					log.Warnf("deps could not check module=%s@%s in proxy, err=%v", dep.Path, dep.Version, err)
+				}
+			}
+		}()
+	}
+	for i := range list {
+		if !local[list[i].Path] {
+			queue <- &list[i]
+		}
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// check fills the latest version, retraction, deprecation and the newer
+// major version of dep from proxy.
+func (d deps) check(ctx context.Context, dep *types.Dependency) error {
+	latest, err := d.latest(ctx, dep.Path, semver.Major(dep.Version))
+	if err != nil {
+		return err
+	}
+	dep.Latest = latest
+
+	// retractions and deprecation are declared in go.mod of the latest version
+	data, err := d.fetch(ctx, dep.Path, "@v/"+latest+".mod")
+	if err != nil {
+		return err
+	}
+	f, err := modfile.ParseLax("go.mod", data, nil)
+	if err != nil {
+		return errors.Wrapf(err, "invalid go.mod of %s@%s", dep.Path, latest)
+	}
+	if f.Module != nil {
+		dep.Deprecated = f.Module.Deprecated
+	}
+	for _, r := range f.Retract {
+		if semver.Compare(dep.Version, r.Low) >= 0 && semver.Compare(dep.Version, r.High) <= 0 {
+			dep.Retracted = r.Rationale
+			if dep.Retracted == "" {
+				dep.Retracted = "no rationale"
+			}
+			break
+		}
+	}
+
+	dep.Major, err = d.newerMajor(ctx, dep.Path)
+	return err
+}
+
+// latest returns the latest release of the major version in proxy,
+// pre-releases are considered only if there is no release.
+func (d deps) latest(ctx context.Context, path, major string) (string, error) {
+	data, err := d.fetch(ctx, path, "@v/list")
+	if err != nil && err != errNotInProxy {
+		return "", err
+	}
+
+	var releases, prereleases []string
+	for _, v := range strings.Fields(string(data)) {
+		if !semver.IsValid(v) || !sameMajor(semver.Major(v), major) || strings.HasSuffix(v, "+incompatible") {
+			continue
+		}
+		if semver.Prerelease(v) != "" {
+			prereleases = append(prereleases, v)
+			continue
+		}
+		releases = append(releases, v)
+	}
+	if len(releases) == 0 {
+		releases = prereleases
+	}
+	if len(releases) != 0 {
+		semver.Sort(releases)
+		return releases[len(releases)-1], nil
+	}
+
+	// pseudo-versions are not listed
+	if data, err = d.fetch(ctx, path, "@latest"); err != nil {
+		return "", err
+	}
+	var info struct{ Version string }
+	if err = json.Unmarshal(data, &info); err != nil {
+		return "", errors.Wrapf(err, "invalid @latest of %s", path)
+	}
+	return info.Version, nil
+}
+
+// sameMajor reports whether major versions a and b share the module path,
+// v0 and v1 have no major version suffix.
+func sameMajor(a, b string) bool {
+	if a == "v0" {
+		a = "v1"
+	}
+	if b == "v0" {
+		b = "v1"
+	}
+	return a == b
+}
+
+// newerMajor returns path of the newest major version of module path in
+// proxy, empty if path is the newest one. Results are cached in _majorCache.
+func (d deps) newerMajor(ctx context.Context, path string) (string, error) {
+	key := d.proxy + " " + path
+	if newest, ok := _majorCache.get(key); ok {
+		return newest, nil
+	}
+
+	newest, err := d.probeMajor(ctx, path)
+	if err == nil {
+		_majorCache.put(key, newest)
+	}
+	return newest, err
+}
+
+// probeMajor probes newer major versions of module path in proxy one by one,
+// at most _maxMajorProbes ones.
+func (d deps) probeMajor(ctx context.Context, path string) (string, error) {
+	prefix, pathMajor, ok := gomodule.SplitPathVersion(path)
+	if !ok {
+		return "", nil
+	}
+
+	major := 1
+	if pathMajor != "" {
+		n, err := strconv.Atoi(strings.TrimLeft(pathMajor, "/.v"))
+		if err != nil {
+			return "", nil
+		}
+		major = n
+	}
+	sep := "/v"
+	if strings.HasPrefix(prefix, "gopkg.in/") {
+		sep = ".v"
+	}
+
+	newest := ""
+	for i := 1; i <= _maxMajorProbes; i++ {
+		candidate := prefix + sep + strconv.Itoa(major+i)
+		data, err := d.fetch(ctx, candidate, "@v/list")
+		if err == errNotInProxy || (err == nil && len(bytes.TrimSpace(data)) == 0) {
+			break
+		}
+		if err != nil {
+			return newest, err
+		}
+		newest = candidate
+	}
+
+	return newest, nil
+}
+
+// majorCache is cache of newer major versions by proxy and module path,
+// entries expire after _majorCacheTTL.
+type majorCache struct {
+	mu      sync.Mutex
+	entries map[string]majorEntry
+}
+
+type majorEntry struct {
+	newest  string
+	expires time.Time
+}
+
+func (c *majorCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return "", false
+	}
+	return e.newest, true
+}
+
+func (c *majorCache) put(key, newest string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= _majorCacheSize {
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= _majorCacheSize {
+			// not cached rather than evicting fresh entries
+			return
+		}
+	}
+	c.entries[key] = majorEntry{newest: newest, expires: now.Add(_majorCacheTTL)}
+}
+
+// fetch reads the file of module path from proxy, such as "@v/list".
+func (d deps) fetch(ctx context.Context, path, file string) ([]byte, error) {
+	escaped, err := gomodule.EscapePath(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "deps.EscapePath")
+	}
+	if strings.HasPrefix(file, "@v/") && file != "@v/list" {
+		version := strings.TrimSuffix(strings.TrimPrefix(file, "@v/"), ".mod")
+		if version, err = gomodule.EscapeVersion(version); err != nil {
+			return nil, errors.Wrap(err, "deps.EscapeVersion")
+		}
+		file = "@v/" + version + ".mod"
+	}
+
+	if strings.HasPrefix(d.proxy, "file://") {
+		u, err := url.Parse(d.proxy)
+		if err != nil {
+			return nil, errors.Wrap(err, "deps.Parse")
+		}
+		data, err := ioutil.ReadFile(filepath.Join(filepath.FromSlash(u.Path), filepath.FromSlash(escaped), filepath.FromSlash(file)))
+		if os.IsNotExist(err) {
+			return nil, errNotInProxy
+		}
+		return data, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.proxy+"/"+escaped+"/"+file, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "deps.NewRequest")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "deps.Do")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusGone:
+		return nil, errNotInProxy
+	default:
+		return nil, errors.Errorf("proxy responded %s", resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	return data, errors.Wrap(err, "deps.ReadAll")
+}
+
+// readGoSum reads go.sum, key is "path version" of which either module or
+// its go.mod has a hash. Empty set would be returned if go.sum not exists.
+func readGoSum(filename string) map[string]bool {
+	sums := make(map[string]bool, 64)
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return sums
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		sums[fields[0]+" "+strings.TrimSuffix(fields[1], "/go.mod")] = true
+	}
+	return sums
+}
+
+// takeDependencies moves dependencies out of scores, since they are reported
+// as a section of the result, not the detail of the check.
+func takeDependencies(scores []types.Score) []types.Dependency {
+	var list []types.Dependency
+	for i := range scores {
+		list = append(list, scores[i].Dependencies...)
+		scores[i].Dependencies = nil
+	}
+	return list
+}
@@ -0,0 +1,133 @@
+package linter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+func Test_firstProxy(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies string
+		want    string
+		wantErr bool
+	}{
+		{name: "case 1", proxies: "https://proxy.golang.org,direct", want: "https://proxy.golang.org"},
+		{name: "case 2", proxies: "file:///var/goproxy/|https://goproxy.io", want: "file:///var/goproxy"},
+		{name: "case 3", proxies: "direct", want: ""},
+		{name: "case 4", proxies: "off", want: ""},
+		{name: "case 5", proxies: "ftp://proxy", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := firstProxy(tt.proxies)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("firstProxy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("firstProxy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_deps_ExecuteDetail(t *testing.T) {
+	proxy, _ := filepath.Abs("testdata/goproxy")
+	dir := "testdata/deps"
+
+	tests := []struct {
+		name       string
+		goproxy    string
+		wantDeps   []types.Dependency
+		wantRules  []string
+		percentage float64
+	}{
+		{
+			name:    "case 1",
+			goproxy: "file://" + filepath.ToSlash(proxy),
+			wantDeps: []types.Dependency{
+				{Path: "example.com/fine", Version: "v1.2.0", Sum: true, Latest: "v1.2.0"},
+				{Path: "example.com/local", Version: "v1.0.0", Replace: "../local"},
+				{Path: "example.com/old", Version: "v1.0.0", Indirect: true, Sum: true, Latest: "v1.1.0",
+					Major: "example.com/old/v2", Retracted: "data race in Client", Deprecated: "use example.com/old/v2 instead."},
+			},
+			wantRules:  []string{"local-replace", "retracted", "deprecated", "major-drift"},
+			percentage: 1.0 / 3,
+		},
+		{
+			name:    "case 2",
+			goproxy: "off",
+			wantDeps: []types.Dependency{
+				{Path: "example.com/fine", Version: "v1.2.0", Sum: true},
+				{Path: "example.com/local", Version: "v1.0.0", Replace: "../local"},
+				{Path: "example.com/old", Version: "v1.0.0", Indirect: true, Sum: true},
+			},
+			wantRules:  []string{"local-replace"},
+			percentage: 2.0 / 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := newDeps(&types.LinterOption{
+				Name:     "deps",
+				Settings: map[string]interface{}{"goproxy": tt.goproxy},
+			})
+			if err != nil {
+				t.Fatalf("newDeps() error = %v", err)
+			}
+
+			score := types.Score{}
+			if err = l.(deps).ExecuteDetail(Context{Dir: dir, Branch: types.MasterBranch}, &score); err != nil {
+				t.Fatalf("ExecuteDetail() error = %v", err)
+			}
+			if !reflect.DeepEqual(score.Dependencies, tt.wantDeps) {
+				t.Errorf("ExecuteDetail() dependencies = %+v, want %+v", score.Dependencies, tt.wantDeps)
+			}
+
+			var rules []string
+			for _, summary := range score.Summaries {
+				for _, e := range summary.Errors {
+					rules = append(rules, e.Rule)
+				}
+			}
+			if !reflect.DeepEqual(rules, tt.wantRules) {
+				t.Errorf("ExecuteDetail() rules = %v, want %v", rules, tt.wantRules)
+			}
+			if score.Percentage != tt.percentage {
+				t.Errorf("ExecuteDetail() percentage = %v, want %v", score.Percentage, tt.percentage)
+			}
+		})
+	}
+}
+
+func Test_deps_newerMajor_cached(t *testing.T) {
+	proxy := writeModule(t, map[string]string{
+		"example.com/cached/@v/list":    "v1.0.0\n",
+		"example.com/cached/v2/@v/list": "v2.0.0\n",
+	})
+	d := deps{proxy: "file://" + filepath.ToSlash(proxy)}
+
+	newerMajor := func() string {
+		newest, err := d.newerMajor(context.Background(), "example.com/cached")
+		if err != nil {
+			t.Fatalf("newerMajor() error = %v", err)
+		}
+		return newest
+	}
+	if got := newerMajor(); got != "example.com/cached/v2" {
+		t.Fatalf("newerMajor() = %v, want example.com/cached/v2", got)
+	}
+
+	// the proxy is not probed again
+	if err := os.RemoveAll(filepath.Join(proxy, "example.com", "cached", "v2")); err != nil {
+		t.Fatal(err)
+	}
+	if got := newerMajor(); got != "example.com/cached/v2" {
+		t.Errorf("newerMajor() = %v, want cached example.com/cached/v2", got)
+	}
+}
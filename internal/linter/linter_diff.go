@@ -0,0 +1,198 @@
+package linter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+// changeSet is lines changed since the base ref, key is path relative to
+// root of repo.
+type changeSet struct {
+	base  string
+	files map[string][]lineRange
+}
+
+// hasFile reports whether the file is changed, path is relative to root of repo.
+func (cs *changeSet) hasFile(path string) bool {
+	_, ok := cs.files[filepath.ToSlash(path)]
+	return ok
+}
+
+// hasLine reports whether the line of file is changed.
+func (cs *changeSet) hasLine(path string, line int) bool {
+	for _, r := range cs.files[filepath.ToSlash(path)] {
+		if line >= r.start && line <= r.end {
+			return true
+		}
+	}
+	return false
+}
+
+// gitChanges computes changed lines of the working tree in root since the
+// merge base of base and HEAD, all lines of untracked files are changed. If
+// base is not a local ref, origin/base would be tried.
+func gitChanges(ctx context.Context, root, base string) (*changeSet, error) {
+	ref := base
+	if _, err := git(ctx, root, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		ref = "origin/" + base
+		if _, err = git(ctx, root, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+			return nil, errors.Errorf("gitChanges: unknown base ref %q", base)
+		}
+	}
+
+	mergeBase, err := git(ctx, root, "merge-base", ref, "HEAD")
+	if err != nil {
+		return nil, errors.Wrap(err, "gitChanges.mergeBase")
+	}
+	diff, err := git(ctx, root, "-c", "core.quotePath=false", "diff", "--relative", "--no-color", "--no-ext-diff", "--unified=0",
+		strings.TrimSpace(string(mergeBase)), "--", ".")
+	if err != nil {
+		return nil, errors.Wrap(err, "gitChanges.diff")
+	}
+
+	untracked, err := git(ctx, root, "ls-files", "-z", "--others", "--exclude-standard", "--", ".")
+	if err != nil {
+		return nil, errors.Wrap(err, "gitChanges.lsFiles")
+	}
+
+	cs := parseUnifiedDiff(diff)
+	cs.base = base
+	for _, file := range strings.Split(string(untracked), "\x00") {
+		if file == "" {
+			continue
+		}
+		cs.files[file] = []lineRange{{start: 1, end: math.MaxInt32}}
+	}
+	return cs, nil
+}
+
+func git(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// newHunkHeader matches "@@ -l,s +l,s @@" of unified diff, and captures
+// start and count of new lines.
+var newHunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// parseUnifiedDiff parses added and modified lines of files from unified diff
+// with zero context lines, deleted files are ignored.
+func parseUnifiedDiff(diff []byte) *changeSet {
+	cs := &changeSet{files: make(map[string][]lineRange, 16)}
+
+	var (
+		file    string
+		scanner = bufio.NewScanner(bytes.NewReader(diff))
+	)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			file = ""
+			if name := strings.TrimPrefix(line, "+++ "); strings.HasPrefix(name, "b/") {
+				file = strings.TrimPrefix(name, "b/")
+				// the file is changed, even if lines are only deleted
+				cs.files[file] = nil
+			}
+		case strings.HasPrefix(line, "@@ ") && file != "":
+			m := newHunkHeader.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			start, _ := strconv.Atoi(m[1])
+			count := 1
+			if m[2] != "" {
+				count, _ = strconv.Atoi(m[2])
+			}
+			if count == 0 {
+				// only deleted lines
+				continue
+			}
+			cs.files[file] = append(cs.files[file], lineRange{start: start, end: start + count - 1})
+		}
+	}
+
+	return cs
+}
+
+// filterChanges keeps issues on changed lines in score, and counts the others
+// into score.Existing. Diff of gofmt is kept if its file is changed. It reports
+// whether summaries are changed.
+func (c Context) filterChanges(score *types.Score) bool {
+	if c.changes == nil {
+		return false
+	}
+
+	existing := 0
+	kept := score.Summaries[:0]
+	for _, summary := range score.Summaries {
+		path := c.rootRelative(summary.Filename)
+		if summary.Diff != "" {
+			if c.changes.hasFile(path) {
+				kept = append(kept, summary)
+			} else {
+				existing += len(summary.Errors)
+			}
+			continue
+		}
+
+		errs := summary.Errors[:0]
+		for _, err := range summary.Errors {
+			if c.changes.hasLine(path, err.LineNumber) {
+				errs = append(errs, err)
+				continue
+			}
+			existing++
+		}
+		if len(errs) == 0 {
+			continue
+		}
+		summary.Errors = errs
+		kept = append(kept, summary)
+	}
+	score.Summaries = kept
+	score.Existing += existing
+
+	return existing != 0
+}
+
+// changedOnly returns Context to grade changed files only in diff-aware
+// linting, or c itself if there is no base.
+func (c Context) changedOnly() Context {
+	if c.changes == nil {
+		return c
+	}
+
+	filenames := make([]string, 0, len(c.Filenames))
+	for _, filename := range c.Filenames {
+		rel, err := filepath.Rel(c.Dir, filename)
+		if err != nil {
+			continue
+		}
+		if c.changes.hasFile(c.rootRelative(rel)) {
+			filenames = append(filenames, filename)
+		}
+	}
+	c.Filenames = filenames
+	c.scorer = c.changedScorer
+	return c
+}
@@ -0,0 +1,125 @@
+package linter
+
+import (
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+func Test_parseUnifiedDiff(t *testing.T) {
+	diff := []byte(`diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -3,0 +4,2 @@ func a() {
++	x := 1
++	_ = x
+@@ -10 +12 @@ func b() {
+-	return
++	return nil
+@@ -20,2 +21,0 @@ func c() {
+-	a()
+-	b()
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,3 +0,0 @@
+-package a
+diff --git a/sub/b.go b/sub/b.go
+--- a/sub/b.go
++++ b/sub/b.go
+@@ -1,2 +1,2 @@
+-package b
++package sub
+`)
+
+	want := map[string][]lineRange{
+		"a.go":     {{start: 4, end: 5}, {start: 12, end: 12}},
+		"sub/b.go": {{start: 1, end: 2}},
+	}
+	if got := parseUnifiedDiff(diff).files; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseUnifiedDiff() = %v, want %v", got, want)
+	}
+}
+
+func TestContext_filterChanges(t *testing.T) {
+	ctx := Context{
+		Dir:  "/repo/sub",
+		root: "/repo",
+		changes: &changeSet{files: map[string][]lineRange{
+			"sub/a.go": {{start: 4, end: 5}},
+			"sub/b.go": nil,
+		}},
+	}
+	score := types.Score{
+		Summaries: []types.FileSummary{
+			{Filename: "a.go", Errors: []types.Error{{LineNumber: 3}, {LineNumber: 4}, {LineNumber: 6}}},
+			{Filename: "b.go", Errors: []types.Error{{LineNumber: 1}}, Diff: "@@ -1 +1 @@"},
+			{Filename: "c.go", Errors: []types.Error{{LineNumber: 1}}},
+		},
+	}
+
+	if changed := ctx.filterChanges(&score); !changed {
+		t.Errorf("filterChanges() = false, want true")
+	}
+	want := []types.FileSummary{
+		{Filename: "a.go", Errors: []types.Error{{LineNumber: 4}}},
+		{Filename: "b.go", Errors: []types.Error{{LineNumber: 1}}, Diff: "@@ -1 +1 @@"},
+	}
+	if !reflect.DeepEqual(score.Summaries, want) {
+		t.Errorf("filterChanges() summaries = %+v, want %+v", score.Summaries, want)
+	}
+	if score.Existing != 3 {
+		t.Errorf("filterChanges() existing = %d, want 3", score.Existing)
+	}
+}
+
+func Test_gitChanges(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v, %s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "-q", "-b", "main")
+	write("a.go", "package a\n\nfunc a() {}\n")
+	run("add", "-A")
+	run("commit", "-q", "-m", "init")
+	run("checkout", "-q", "-b", "feature")
+	write("a.go", "package a\n\nfunc a() {}\n\nfunc b() {}\n")
+	run("commit", "-q", "-am", "add b")
+	write("c.go", "package a\n")
+
+	cs, err := gitChanges(context.Background(), dir, "main")
+	if err != nil {
+		t.Fatalf("gitChanges() error = %v", err)
+	}
+	if !cs.hasLine("a.go", 5) || cs.hasLine("a.go", 3) {
+		t.Errorf("gitChanges() a.go = %v, want line 5 changed only", cs.files["a.go"])
+	}
+	if !cs.hasLine("c.go", 1) {
+		t.Errorf("gitChanges() untracked c.go is not changed")
+	}
+
+	if _, err = gitChanges(context.Background(), dir, "nope"); err == nil {
+		t.Errorf("gitChanges() with unknown base, want error")
+	}
+}
@@ -0,0 +1,248 @@
+package linter
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yeqown/goreportcard/internal/types"
+	"github.com/yeqown/log"
+)
+
+var _ IDetailLinter = doc{}
+
+// doc computes documentation coverage of exported functions, types, methods
+// of exported types, constants and package clauses. Undocumented ones and
+// doc comments which don't start with the name are reported. The percentage
+// is the share of documented ones, and a doc comment which doesn't start
+// with the name counts half. Coverage per package is reported in
+// types.Score.Docs.
+type doc struct {
+	name   string  // linter's name
+	desc   string  // linter's desc
+	weight float64 // linter's weight
+}
+
+func newDoc(opt *types.LinterOption) (ILinter, error) {
+	return doc{
+		name:   opt.Name,
+		desc:   opt.Desc,
+		weight: opt.Weight,
+	}, nil
+}
+
+func (d doc) Name() string {
+	return d.name
+}
+
+func (d doc) Description() string {
+	return d.desc
+}
+
+func (d doc) Weight() float64 {
+	return d.weight
+}
+
+func (d doc) Execute(ctx Context) (float64, []types.FileSummary, error) {
+	score := types.Score{}
+	err := d.ExecuteDetail(ctx, &score)
+	return score.Percentage, score.Summaries, err
+}
+
+// docSymbol is an exported identifier or package clause to document
+type docSymbol struct {
+	kind    string // func, method, type, const or package
+	name    string // name to start the doc comment with
+	display string // name in message, such as T.Method
+	pos     token.Position
+	doc     *ast.CommentGroup
+	grouped bool // doc comment of the group, it could not start with the name
+}
+
+func (d doc) ExecuteDetail(ctx Context, score *types.Score) error {
+	var (
+		fset      = token.NewFileSet()
+		collector = newSummaryCollector(ctx)
+		packages  = make(map[string]*types.PackageDocs, 16) // map[dir]docs
+		pkgClause = make(map[string]docSymbol, 16)          // map[dir]package clause
+	)
+
+	filenames := append([]string{}, ctx.Filenames...)
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		f, err := parser.ParseFile(fset, filename, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			// file could not be parsed, typecheck would report it
+			log.Warnf("doc failed to parse file=%s, err=%v", filename, err)
+			continue
+		}
+
+		rel, _ := filepath.Rel(ctx.Dir, filename)
+		rel = filepath.ToSlash(rel)
+		dir := path.Dir(rel)
+		pkg, ok := packages[dir]
+		if !ok {
+			pkg = &types.PackageDocs{Dir: dir}
+			packages[dir] = pkg
+		}
+
+		// the package is documented if any file has the package doc
+		if clause, ok := pkgClause[dir]; !ok || (clause.doc == nil && f.Doc != nil) {
+			pkgClause[dir] = docSymbol{
+				kind:    "package",
+				name:    "Package " + f.Name.Name,
+				display: f.Name.Name,
+				pos:     fset.Position(f.Name.Pos()),
+				doc:     f.Doc,
+				grouped: f.Name.Name == "main", // doc of command is free-form
+			}
+		}
+
+		for _, sym := range exportedDocSymbols(fset, f) {
+			d.check(collector, pkg, rel, sym)
+		}
+	}
+
+	dirs := make([]string, 0, len(packages))
+	for dir := range packages {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	stats := &types.DocStats{Packages: make([]types.PackageDocs, 0, len(dirs))}
+	for _, dir := range dirs {
+		pkg := packages[dir]
+		clause := pkgClause[dir]
+		rel, _ := filepath.Rel(ctx.Dir, clause.pos.Filename)
+		d.check(collector, pkg, filepath.ToSlash(rel), clause)
+
+		stats.Packages = append(stats.Packages, *pkg)
+		stats.Add(pkg.DocCounts)
+	}
+
+	score.Summaries = collector.summaries()
+	score.Docs = stats
+	score.Percentage = stats.Coverage()
+	return nil
+}
+
+// check counts sym into pkg, and reports it if it's not well documented.
+func (d doc) check(collector *summaryCollector, pkg *types.PackageDocs, filename string, sym docSymbol) {
+	pkg.Exported++
+	if sym.doc == nil {
+		collector.add(filename, types.Error{
+			LineNumber:  sym.pos.Line,
+			Column:      sym.pos.Column,
+			Rule:        "undocumented",
+			ErrorString: fmt.Sprintf("exported %s %s should have a doc comment", sym.kind, sym.display),
+		})
+		return
+	}
+
+	pkg.Documented++
+	if sym.grouped || startsWithName(sym.doc.Text(), sym.name) {
+		return
+	}
+	pkg.Misnamed++
+	collector.add(filename, types.Error{
+		LineNumber:  sym.pos.Line,
+		Column:      sym.pos.Column,
+		Rule:        "doc-prefix",
+		Severity:    "info",
+		ErrorString: fmt.Sprintf("doc comment of %s %s should start with %q", sym.kind, sym.display, sym.name),
+	})
+}
+
+// startsWithName reports whether text starts with name, articles before name
+// are allowed, such as "A Client is ...".
+func startsWithName(text, name string) bool {
+	for _, article := range []string{"", "A ", "An ", "The "} {
+		rest := strings.TrimPrefix(text, article)
+		if article != "" && rest == text {
+			continue
+		}
+		if strings.HasPrefix(rest, name) {
+			next := strings.TrimPrefix(rest, name)
+			if next == "" || !isIdentRune(next[0]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isIdentRune(b byte) bool {
+	return b == '_' || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+// exportedDocSymbols returns exported functions, types, methods of exported
+// types and constants declared in f.
+func exportedDocSymbols(fset *token.FileSet, f *ast.File) []docSymbol {
+	symbols := make([]docSymbol, 0, len(f.Decls))
+	for _, decl := range f.Decls {
+		switch dl := decl.(type) {
+		case *ast.FuncDecl:
+			if !dl.Name.IsExported() {
+				continue
+			}
+			kind := "func"
+			if dl.Recv != nil {
+				if !ast.IsExported(receiverName(dl.Recv)) {
+					continue
+				}
+				kind = "method"
+			}
+			symbols = append(symbols, docSymbol{
+				kind:    kind,
+				name:    dl.Name.Name,
+				display: funcName(dl),
+				pos:     fset.Position(dl.Name.Pos()),
+				doc:     dl.Doc,
+			})
+		case *ast.GenDecl:
+			if dl.Tok != token.TYPE && dl.Tok != token.CONST {
+				continue
+			}
+			grouped := dl.Lparen.IsValid()
+			for _, spec := range dl.Specs {
+				switch sp := spec.(type) {
+				case *ast.TypeSpec:
+					if sp.Name.IsExported() {
+						symbols = append(symbols, specSymbol("type", sp.Name, sp.Doc, dl, grouped, fset))
+					}
+				case *ast.ValueSpec:
+					for _, name := range sp.Names {
+						if name.IsExported() {
+							symbols = append(symbols, specSymbol("const", name, sp.Doc, dl, grouped, fset))
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return symbols
+}
+
+// specSymbol is the symbol of name declared in a spec of decl, the doc of
+// a grouped decl documents all specs in the group.
+func specSymbol(kind string, name *ast.Ident, specDoc *ast.CommentGroup, decl *ast.GenDecl,
+	grouped bool, fset *token.FileSet) docSymbol {
+	sym := docSymbol{
+		kind:    kind,
+		name:    name.Name,
+		display: name.Name,
+		pos:     fset.Position(name.Pos()),
+		doc:     specDoc,
+	}
+	if sym.doc == nil {
+		sym.doc = decl.Doc
+		sym.grouped = grouped
+	}
+	return sym
+}
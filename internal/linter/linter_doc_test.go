@@ -0,0 +1,85 @@
+package linter
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+func Test_startsWithName(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want bool
+	}{
+		{name: "case 1", text: "Client talks to the server.", want: true},
+		{name: "case 2", text: "A Client talks to the server.", want: true},
+		{name: "case 3", text: "ClientX talks to the server.", want: false},
+		{name: "case 4", text: "talks to the server.", want: false},
+		{name: "case 5", text: "Client", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := startsWithName(tt.text, "Client"); got != tt.want {
+				t.Errorf("startsWithName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_doc_ExecuteDetail(t *testing.T) {
+	dir := "testdata/doc"
+	ctx := Context{
+		Dir: dir,
+		Filenames: []string{
+			filepath.Join(dir, "a.go"),
+			filepath.Join(dir, "b.go"),
+			filepath.Join(dir, "sub", "c.go"),
+		},
+		Branch: types.MasterBranch,
+	}
+
+	l, _ := newDoc(&types.LinterOption{Name: "doc"})
+	score := types.Score{}
+	if err := l.(doc).ExecuteDetail(ctx, &score); err != nil {
+		t.Fatalf("ExecuteDetail() error = %v", err)
+	}
+
+	want := &types.DocStats{
+		DocCounts: types.DocCounts{Exported: 13, Documented: 10, Misnamed: 2},
+		Packages: []types.PackageDocs{
+			{Dir: ".", DocCounts: types.DocCounts{Exported: 11, Documented: 9, Misnamed: 2}},
+			{Dir: "sub", DocCounts: types.DocCounts{Exported: 2, Documented: 1}},
+		},
+	}
+	if !reflect.DeepEqual(score.Docs, want) {
+		t.Errorf("ExecuteDetail() docs = %+v, want %+v", score.Docs, want)
+	}
+	if score.Percentage != 9.0/13 {
+		t.Errorf("ExecuteDetail() percentage = %v, want %v", score.Percentage, 9.0/13)
+	}
+
+	type finding struct {
+		filename string
+		line     int
+		rule     string
+	}
+	var got []finding
+	for _, summary := range score.Summaries {
+		for _, e := range summary.Errors {
+			got = append(got, finding{summary.Filename, e.LineNumber, e.Rule})
+		}
+	}
+	wantFindings := []finding{
+		{"a.go", 11, "doc-prefix"},
+		{"a.go", 13, "undocumented"},
+		{"b.go", 12, "undocumented"},
+		{"b.go", 18, "doc-prefix"},
+		{"sub/c.go", 1, "undocumented"},
+	}
+	if !reflect.DeepEqual(got, wantFindings) {
+		t.Errorf("ExecuteDetail() findings = %+v, want %+v", got, wantFindings)
+	}
+}
@@ -0,0 +1,347 @@
+package linter
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/yeqown/goreportcard/internal/types"
+	"github.com/yeqown/log"
+)
+
+var _ IDetailLinter = duplicate{}
+
+// duplicate finds copy-pasted code in function bodies. Bodies are split into
+// sequences of tokens by go/scanner, identifiers and literals are normalized,
+// so renamed copies are also found. Sequences of at least minTokens tokens
+// which appear more than once make a clone group. The percentage is
+// 1 - duplicated lines / lines, clone groups are reported in
+// types.Score.Duplicates.
+type duplicate struct {
+	name   string  // linter's name
+	desc   string  // linter's desc
+	weight float64 // linter's weight
+
+	minTokens int // minimum count of tokens of duplicated code
+}
+
+func newDuplicate(opt *types.LinterOption) (ILinter, error) {
+	d := duplicate{
+		name:   opt.Name,
+		desc:   opt.Desc,
+		weight: opt.Weight,
+	}
+
+	var err error
+	if d.minTokens, err = settingInt(opt.Settings, "min-tokens", 100); err != nil {
+		return nil, errors.Wrap(err, "duplicate")
+	}
+	if d.minTokens <= 0 {
+		return nil, errors.Errorf("duplicate: settings.min-tokens should be positive, but got %d", d.minTokens)
+	}
+
+	return d, nil
+}
+
+func (d duplicate) Name() string {
+	return d.name
+}
+
+func (d duplicate) Description() string {
+	return d.desc
+}
+
+func (d duplicate) Weight() float64 {
+	return d.weight
+}
+
+func (d duplicate) Execute(ctx Context) (float64, []types.FileSummary, error) {
+	score := types.Score{}
+	err := d.ExecuteDetail(ctx, &score)
+	return score.Percentage, score.Summaries, err
+}
+
+// dupToken is a token of function body, automatically inserted semicolons
+// are not counted.
+type dupToken struct {
+	kind     uint64 // type of token, identifiers and literals of the same kind are the same
+	pos, end token.Pos
+}
+
+// dupUnit is tokens of the body of a function
+type dupUnit struct {
+	filename string // relative to ctx.Dir
+	tokens   []dupToken
+}
+
+// dupPos is the start of a token sequence in units
+type dupPos struct {
+	unit, index int
+}
+
+func (d duplicate) ExecuteDetail(ctx Context, score *types.Score) error {
+	var (
+		fset  = token.NewFileSet()
+		units []dupUnit
+		lines int
+	)
+
+	filenames := append([]string{}, ctx.Filenames...)
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return errors.Wrap(err, "duplicate.ReadFile")
+		}
+		f, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+		if err != nil {
+			// file could not be parsed, typecheck would report it
+			log.Warnf("duplicate failed to parse file=%s, err=%v", filename, err)
+			continue
+		}
+		file := fset.File(f.Pos())
+		lines += file.LineCount()
+
+		rel, _ := filepath.Rel(ctx.Dir, filename)
+		rel = filepath.ToSlash(rel)
+		tokens := scanTokens(file, src)
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			// tokens between braces of body
+			start := sort.Search(len(tokens), func(i int) bool { return tokens[i].pos > fn.Body.Lbrace })
+			end := sort.Search(len(tokens), func(i int) bool { return tokens[i].pos >= fn.Body.Rbrace })
+			if end-start >= d.minTokens {
+				units = append(units, dupUnit{filename: rel, tokens: tokens[start:end]})
+			}
+		}
+	}
+
+	groups := findClones(units, d.minTokens)
+	stats := &types.DupStats{
+		Lines:  lines,
+		Groups: make([]types.CloneGroup, 0, len(groups)),
+	}
+	duplicated := make(map[string]map[int]struct{}, 16) // map[filename]lines
+	for _, group := range groups {
+		clone := types.CloneGroup{
+			Tokens:    group.length,
+			Locations: make([]types.CloneLocation, 0, len(group.positions)),
+		}
+		for _, p := range group.positions {
+			unit := units[p.unit]
+			tokens := unit.tokens[p.index : p.index+group.length]
+			start := fset.Position(tokens[0].pos).Line
+			end := fset.Position(tokens[len(tokens)-1].end - 1).Line
+			clone.Locations = append(clone.Locations, types.CloneLocation{
+				Filename:  unit.filename,
+				FileURL:   assembleRemoteFileURI(ctx.Dir, ctx.Branch, unit.filename),
+				StartLine: start,
+				EndLine:   end,
+			})
+
+			if duplicated[unit.filename] == nil {
+				duplicated[unit.filename] = make(map[int]struct{}, end-start+1)
+			}
+			for line := start; line <= end; line++ {
+				duplicated[unit.filename][line] = struct{}{}
+			}
+		}
+		stats.Groups = append(stats.Groups, clone)
+	}
+	for _, fileLines := range duplicated {
+		stats.DuplicatedLines += len(fileLines)
+	}
+	sortCloneGroups(stats.Groups)
+
+	collector := newSummaryCollector(ctx)
+	for _, group := range stats.Groups {
+		for i, loc := range group.Locations {
+			others := make([]string, 0, len(group.Locations)-1)
+			for j, other := range group.Locations {
+				if j != i {
+					others = append(others, fmt.Sprintf("%s:%d-%d", other.Filename, other.StartLine, other.EndLine))
+				}
+			}
+			collector.add(loc.Filename, types.Error{
+				LineNumber: loc.StartLine,
+				Rule:       "duplicate",
+				ErrorString: fmt.Sprintf("lines %d-%d are duplicated in %s",
+					loc.StartLine, loc.EndLine, strings.Join(others, ", ")),
+			})
+		}
+	}
+
+	score.Summaries = collector.summaries()
+	score.Duplicates = stats
+	score.Percentage = stats.Unique()
+	return nil
+}
+
+// scanTokens returns tokens of src, comments and automatically inserted
+// semicolons are skipped. file is the token.File of src which is parsed.
+func scanTokens(file *token.File, src []byte) []dupToken {
+	var (
+		s      scanner.Scanner
+		tokens = make([]dupToken, 0, len(src)/4)
+	)
+	s.Init(file, src, nil, 0)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit != ";" {
+			continue
+		}
+
+		length := len(lit)
+		if length == 0 {
+			length = len(tok.String())
+		}
+		tokens = append(tokens, dupToken{kind: uint64(tok), pos: pos, end: pos + token.Pos(length)})
+	}
+	return tokens
+}
+
+// cloneGroup is the same token sequence of length at positions
+type cloneGroup struct {
+	length    int
+	positions []dupPos
+}
+
+// findClones finds token sequences which are longer than minTokens and appear
+// more than once in units, by hashing every window of minTokens tokens. Windows
+// with the same hash are extended to the longest common sequence, and windows
+// inside a longer sequence are skipped.
+func findClones(units []dupUnit, minTokens int) []cloneGroup {
+	const base = 1000003
+	pow := uint64(1) // base^(minTokens-1)
+	for i := 1; i < minTokens; i++ {
+		pow *= base
+	}
+
+	var (
+		buckets = make(map[uint64][]dupPos, 1024)
+		order   []uint64 // hashes in order of first appearance
+	)
+	for u, unit := range units {
+		var h uint64
+		for i, tok := range unit.tokens {
+			if i >= minTokens {
+				h -= unit.tokens[i-minTokens].kind * pow
+			}
+			h = h*base + tok.kind
+			if i < minTokens-1 {
+				continue
+			}
+			if _, ok := buckets[h]; !ok {
+				order = append(order, h)
+			}
+			buckets[h] = append(buckets[h], dupPos{unit: u, index: i - minTokens + 1})
+		}
+	}
+
+	kind := func(p dupPos, offset int) (uint64, bool) {
+		tokens := units[p.unit].tokens
+		if i := p.index + offset; i >= 0 && i < len(tokens) {
+			return tokens[i].kind, true
+		}
+		return 0, false
+	}
+	// same reports whether tokens at offset of all positions are the same
+	same := func(positions []dupPos, offset int) bool {
+		first, ok := kind(positions[0], offset)
+		if !ok {
+			return false
+		}
+		for _, p := range positions[1:] {
+			if k, ok := kind(p, offset); !ok || k != first {
+				return false
+			}
+		}
+		return true
+	}
+
+	var groups []cloneGroup
+	for _, h := range order {
+		bucket := buckets[h]
+		if len(bucket) < 2 {
+			continue
+		}
+
+		// keep windows equal to the first, and not overlapping in the same unit
+		positions := make([]dupPos, 0, len(bucket))
+		for _, p := range bucket {
+			if n := len(positions); n > 0 {
+				last := positions[n-1]
+				if last.unit == p.unit && p.index < last.index+minTokens {
+					continue
+				}
+				if !sameTokens(units, positions[0], p, minTokens) {
+					// hash collision
+					continue
+				}
+			}
+			positions = append(positions, p)
+		}
+		if len(positions) < 2 || same(positions, -1) {
+			// part of a longer sequence starting earlier
+			continue
+		}
+
+		length := minTokens
+		for same(positions, length) && !overlapped(positions, length+1) {
+			length++
+		}
+		groups = append(groups, cloneGroup{length: length, positions: positions})
+	}
+
+	return groups
+}
+
+func sameTokens(units []dupUnit, a, b dupPos, length int) bool {
+	x := units[a.unit].tokens[a.index : a.index+length]
+	y := units[b.unit].tokens[b.index : b.index+length]
+	for i := range x {
+		if x[i].kind != y[i].kind {
+			return false
+		}
+	}
+	return true
+}
+
+// overlapped reports whether sequences of length at positions overlap,
+// positions are sorted.
+func overlapped(positions []dupPos, length int) bool {
+	for i := 1; i < len(positions); i++ {
+		prev, p := positions[i-1], positions[i]
+		if prev.unit == p.unit && p.index < prev.index+length {
+			return true
+		}
+	}
+	return false
+}
+
+// sortCloneGroups sorts groups by duplicated lines, the most first
+func sortCloneGroups(groups []types.CloneGroup) {
+	lines := func(g types.CloneGroup) int {
+		n := 0
+		for _, loc := range g.Locations {
+			n += loc.EndLine - loc.StartLine + 1
+		}
+		return n
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return lines(groups[i]) > lines(groups[j])
+	})
+}
@@ -0,0 +1,125 @@
+package linter
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+func Test_findClones(t *testing.T) {
+	unit := func(kinds ...uint64) dupUnit {
+		tokens := make([]dupToken, 0, len(kinds))
+		for _, k := range kinds {
+			tokens = append(tokens, dupToken{kind: k})
+		}
+		return dupUnit{tokens: tokens}
+	}
+
+	tests := []struct {
+		name      string
+		units     []dupUnit
+		minTokens int
+		want      []cloneGroup
+	}{
+		{
+			name:      "case 1",
+			units:     []dupUnit{unit(1, 2, 3, 4, 5), unit(9, 1, 2, 3, 4, 8)},
+			minTokens: 3,
+			want:      []cloneGroup{{length: 4, positions: []dupPos{{0, 0}, {1, 1}}}},
+		},
+		{
+			name:      "case 2",
+			units:     []dupUnit{unit(1, 2, 1, 2, 1, 2)},
+			minTokens: 2,
+			want:      []cloneGroup{{length: 2, positions: []dupPos{{0, 0}, {0, 2}, {0, 4}}}},
+		},
+		{
+			name:      "case 3",
+			units:     []dupUnit{unit(1, 2, 3), unit(4, 5, 6)},
+			minTokens: 2,
+			want:      nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findClones(tt.units, tt.minTokens); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findClones() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_duplicate_ExecuteDetail(t *testing.T) {
+	dir := "testdata/duplicate"
+	ctx := Context{
+		Dir: dir,
+		Filenames: []string{
+			filepath.Join(dir, "a.go"),
+			filepath.Join(dir, "b.go"),
+			filepath.Join(dir, "c.go"),
+		},
+		Branch: types.MasterBranch,
+	}
+
+	l, err := newDuplicate(&types.LinterOption{
+		Name:     "duplicate",
+		Settings: map[string]interface{}{"min-tokens": int64(20)},
+	})
+	if err != nil {
+		t.Fatalf("newDuplicate() error = %v", err)
+	}
+	score := types.Score{}
+	if err = l.(duplicate).ExecuteDetail(ctx, &score); err != nil {
+		t.Fatalf("ExecuteDetail() error = %v", err)
+	}
+
+	// body of sum in a.go (26 tokens) is copied into b.go with renamed
+	// identifiers, product of c.go shares only 13 tokens with it
+	want := &types.DupStats{
+		Lines:           49,
+		DuplicatedLines: 16,
+		Groups: []types.CloneGroup{{
+			Tokens: 26,
+			Locations: []types.CloneLocation{
+				{Filename: "a.go", FileURL: assembleRemoteFileURI(dir, types.MasterBranch, "a.go"), StartLine: 4, EndLine: 11},
+				{Filename: "b.go", FileURL: assembleRemoteFileURI(dir, types.MasterBranch, "b.go"), StartLine: 7, EndLine: 14},
+			},
+		}},
+	}
+	if !reflect.DeepEqual(score.Duplicates, want) {
+		t.Errorf("ExecuteDetail() duplicates = %+v, want %+v", score.Duplicates, want)
+	}
+	if math.Abs(score.Percentage-(1-16.0/49)) > 1e-9 {
+		t.Errorf("ExecuteDetail() percentage = %v, want %v", score.Percentage, 1-16.0/49)
+	}
+
+	var got []string
+	for _, summary := range score.Summaries {
+		for _, e := range summary.Errors {
+			got = append(got, summary.Filename+": "+e.ErrorString)
+		}
+	}
+	wantFindings := []string{
+		"a.go: lines 4-11 are duplicated in b.go:7-14",
+		"b.go: lines 7-14 are duplicated in a.go:4-11",
+	}
+	if !reflect.DeepEqual(got, wantFindings) {
+		t.Errorf("ExecuteDetail() findings = %v, want %v", got, wantFindings)
+	}
+
+	// min-tokens counts tokens of go/scanner
+	l, _ = newDuplicate(&types.LinterOption{
+		Name:     "duplicate",
+		Settings: map[string]interface{}{"min-tokens": int64(27)},
+	})
+	score = types.Score{}
+	if err = l.(duplicate).ExecuteDetail(ctx, &score); err != nil {
+		t.Fatalf("ExecuteDetail() error = %v", err)
+	}
+	if len(score.Duplicates.Groups) != 0 {
+		t.Errorf("ExecuteDetail() with min-tokens=27 groups = %+v, want none", score.Duplicates.Groups)
+	}
+}
@@ -0,0 +1,148 @@
+package linter
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/pkg/errors"
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+// sources of exclude rules
+const (
+	excludeByBuiltin   = "builtin"   // skipDirs and skipSuffixes
+	excludeByInstance  = "instance"  // skipDirs of instance config
+	excludeByRepo      = "repo"      // exclude of `.goreportcard.yml`
+	excludeByCLI       = "cli"       // --exclude flags of CLI
+	excludeByGenerated = "generated" // generated code, see isGenerated
+)
+
+// generatedRule is the rule of generated files
+var generatedRule = excludeRule{pattern: "// Code generated ... DO NOT EDIT.", source: excludeByGenerated}
+
+// excludeRule is a doublestar glob of paths relative to root of repo
+type excludeRule struct {
+	pattern string
+	source  string
+}
+
+// excluder is the only exclusion engine of Lint, it decides which files
+// would be visited by visitGoFiles and which paths would be skipped by
+// golangci-lint, so files count and issues always agree.
+type excluder struct {
+	rules []excludeRule
+}
+
+// newExcluder creates excluder with rules of all sources, repo could be nil.
+func newExcluder(instance []string, repo *repoConfig, cli []string) (*excluder, error) {
+	e := &excluder{
+		rules: make([]excludeRule, 0, len(skipDirs)+len(skipSuffixes)+len(instance)+len(cli)),
+	}
+
+	for _, dir := range skipDirs {
+		e.rules = append(e.rules, excludeRule{pattern: "**/" + dir, source: excludeByBuiltin})
+	}
+	for _, suffix := range skipSuffixes {
+		e.rules = append(e.rules, excludeRule{pattern: "**/*" + suffix, source: excludeByBuiltin})
+	}
+	if err := e.add(instance, excludeByInstance); err != nil {
+		return nil, err
+	}
+	if repo != nil {
+		if err := e.add(repo.Exclude, excludeByRepo); err != nil {
+			return nil, err
+		}
+	}
+	if err := e.add(cli, excludeByCLI); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (e *excluder) add(patterns []string, source string) error {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		if !doublestar.ValidatePattern(pattern) {
+			return errors.Errorf("exclude: invalid glob %q of %s", pattern, source)
+		}
+		e.rules = append(e.rules, excludeRule{pattern: pattern, source: source})
+	}
+	return nil
+}
+
+// match returns the rule which excludes path relative to root of repo, a path
+// is excluded if the path or any of its parent dirs matches a rule.
+func (e *excluder) match(path string) *excludeRule {
+	if e == nil {
+		return nil
+	}
+
+	path = filepath.ToSlash(filepath.Clean(path))
+	for path != "." && path != "" {
+		for idx := range e.rules {
+			if ok, _ := doublestar.Match(e.rules[idx].pattern, path); ok {
+				return &e.rules[idx]
+			}
+		}
+
+		idx := strings.LastIndex(path, "/")
+		if idx < 0 {
+			break
+		}
+		path = path[:idx]
+	}
+
+	return nil
+}
+
+// excludedPath creates types.ExcludedPath of path excluded by rule
+func excludedPath(path string, isDir bool, rule *excludeRule) types.ExcludedPath {
+	return types.ExcludedPath{
+		Path:   filepath.ToSlash(path),
+		Dir:    isDir,
+		Rule:   rule.pattern,
+		Source: rule.source,
+	}
+}
+
+// splitGenerated splits generated files out of excluded paths
+func splitGenerated(paths []types.ExcludedPath) (excluded []types.ExcludedPath, generated []string) {
+	for _, p := range paths {
+		if p.Source == excludeByGenerated {
+			generated = append(generated, p.Path)
+			continue
+		}
+		excluded = append(excluded, p)
+	}
+	return excluded, generated
+}
+
+// golangciSkipArgs converts excluded paths into `--skip-dirs` and `--skip-files`
+// of golangci-lint which runs in ctx.Dir.
+func golangciSkipArgs(ctx Context) []string {
+	root := ctx.root
+	if root == "" {
+		root = ctx.Dir
+	}
+
+	args := make([]string, 0, len(ctx.excluded))
+	for _, p := range ctx.excluded {
+		rel, err := filepath.Rel(ctx.Dir, filepath.Join(root, filepath.FromSlash(p.Path)))
+		if err != nil || strings.HasPrefix(rel, "..") {
+			// not in this module
+			continue
+		}
+
+		pattern := "^" + regexp.QuoteMeta(filepath.ToSlash(rel))
+		if p.Dir {
+			args = append(args, "--skip-dirs="+pattern+"($|/)")
+			continue
+		}
+		args = append(args, "--skip-files="+pattern+"$")
+	}
+
+	return args
+}
@@ -0,0 +1,87 @@
+package linter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+func Test_excluder_match(t *testing.T) {
+	ex, err := newExcluder([]string{"docs"}, &repoConfig{Exclude: []string{"gen/**"}}, []string{"internal/*_mock.go"})
+	if err != nil {
+		t.Fatalf("newExcluder() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want *excludeRule
+	}{
+		{name: "case 0", path: "main.go", want: nil},
+		{name: "case 1", path: "vendor/x/x.go", want: &excludeRule{pattern: "**/vendor", source: excludeByBuiltin}},
+		{name: "case 2", path: "a/b/testdata/c.go", want: &excludeRule{pattern: "**/testdata", source: excludeByBuiltin}},
+		{name: "case 3", path: "api/api.pb.go", want: &excludeRule{pattern: "**/*.pb.go", source: excludeByBuiltin}},
+		{name: "case 4", path: "docs/docs.go", want: &excludeRule{pattern: "docs", source: excludeByInstance}},
+		{name: "case 5", path: "gen/a/b.go", want: &excludeRule{pattern: "gen/**", source: excludeByRepo}},
+		{name: "case 6", path: "internal/db_mock.go", want: &excludeRule{pattern: "internal/*_mock.go", source: excludeByCLI}},
+		{name: "case 7", path: "internal/a/db_mock.go", want: nil},
+		{name: "case 8", path: "mydocs/a.go", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ex.match(tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("match(%q) = %+v, want %+v", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	if _, err = newExcluder([]string{"a/[b"}, nil, nil); err == nil {
+		t.Errorf("newExcluder() with invalid glob, want error")
+	}
+}
+
+func Test_golangciSkipArgs(t *testing.T) {
+	ctx := Context{
+		Dir:  "/repo/sub",
+		root: "/repo",
+		excluded: []types.ExcludedPath{
+			{Path: "sub/gen", Dir: true},
+			{Path: "sub/a.pb.go"},
+			{Path: "other/b.go"},
+		},
+	}
+
+	want := []string{
+		"--skip-dirs=^gen($|/)",
+		`--skip-files=^a\.pb\.go$`,
+	}
+	if got := golangciSkipArgs(ctx); !reflect.DeepEqual(got, want) {
+		t.Errorf("golangciSkipArgs() = %v, want %v", got, want)
+	}
+}
+
+func Test_visitGoFiles_generated(t *testing.T) {
+	dir := "testdata/generated"
+	filenames, excluded, err := visitGoFiles(Context{Dir: dir}, dir)
+	if err != nil {
+		t.Fatalf("visitGoFiles() error = %v", err)
+	}
+
+	wantFilenames := []string{
+		"testdata/generated/after.go",
+		"testdata/generated/handwritten.go",
+		"testdata/generated/inline.go",
+	}
+	if !reflect.DeepEqual(filenames, wantFilenames) {
+		t.Errorf("visitGoFiles() filenames = %v, want %v", filenames, wantFilenames)
+	}
+
+	excluded, generated := splitGenerated(excluded)
+	if len(excluded) != 0 {
+		t.Errorf("splitGenerated() excluded = %v, want empty", excluded)
+	}
+	if want := []string{"buildtag.go", "license.go"}; !reflect.DeepEqual(generated, want) {
+		t.Errorf("splitGenerated() generated = %v, want %v", generated, want)
+	}
+}
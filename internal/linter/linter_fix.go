@@ -0,0 +1,194 @@
+package linter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/yeqown/goreportcard/internal/types"
+	"github.com/yeqown/log"
+)
+
+// FileFix is the automatic fix of one file
+type FileFix struct {
+	Filename string // relative to dir of Lint
+	Fixes    int    // count of fixed issues
+	Src      []byte // content before fixed
+	Fixed    []byte // content after fixed
+}
+
+// lineEdit replaces lines [line, line+count) with newLines, line is 1-based
+type lineEdit struct {
+	line     int
+	count    int
+	newLines []string
+	err      types.Error
+}
+
+// Fixes collects automatic fixes of issues in result which is linted in dir:
+// suggested replacements of golangci-lint and analyzers, and formatting of
+// gofmt linters. Edits which overlap others or don't match the file any more
+// are skipped. Fixes are sorted by filename.
+func Fixes(dir string, result types.LintResult) ([]FileFix, error) {
+	formatters := make(map[string]gofmt, 2) // map[linterName]gofmt
+	linters, err := getLinters()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range linters {
+		if g, ok := l.(gofmt); ok {
+			formatters[g.name] = g
+		}
+	}
+
+	var (
+		edits  = make(map[string][]lineEdit, 16) // map[filename]edits
+		format = make(map[string]gofmt, 16)      // map[filename]formatter
+	)
+	scores := append(append(make([]types.Score, 0, len(result.Scores)+len(result.TestScores)),
+		result.Scores...), result.TestScores...)
+	for _, score := range scores {
+		for _, summary := range score.Summaries {
+			if g, ok := formatters[score.Name]; ok && summary.Diff != "" {
+				format[summary.Filename] = g
+				continue
+			}
+			for _, e := range summary.Errors {
+				if e.Replacement == nil || e.LineNumber <= 0 {
+					continue
+				}
+				count := len(e.SourceLines)
+				if count == 0 {
+					count = 1
+				}
+				edits[summary.Filename] = append(edits[summary.Filename], lineEdit{line: e.LineNumber, count: count, err: e})
+			}
+		}
+	}
+
+	filenames := make([]string, 0, len(edits)+len(format))
+	for filename := range edits {
+		filenames = append(filenames, filename)
+	}
+	for filename := range format {
+		if _, ok := edits[filename]; !ok {
+			filenames = append(filenames, filename)
+		}
+	}
+	sort.Strings(filenames)
+
+	fixes := make([]FileFix, 0, len(filenames))
+	for _, filename := range filenames {
+		src, err := ioutil.ReadFile(filepath.Join(dir, filename))
+		if err != nil {
+			return nil, errors.Wrap(err, "Fixes.ReadFile")
+		}
+
+		fixed, n := applyEdits(src, edits[filename])
+		if g, ok := format[filename]; ok {
+			formatted, err := g.format(filepath.Join(dir, filename), fixed)
+			if err != nil {
+				log.Warnf("Fixes could not format file=%s after fixed, err=%v", filename, err)
+			} else if !bytes.Equal(formatted, fixed) {
+				fixed = formatted
+				n++
+			}
+		}
+		if n == 0 || bytes.Equal(src, fixed) {
+			continue
+		}
+
+		fixes = append(fixes, FileFix{Filename: filename, Fixes: n, Src: src, Fixed: fixed})
+	}
+
+	return fixes, nil
+}
+
+// applyEdits applies edits from the bottom of src, so line numbers of the
+// others are not changed. It returns the fixed content and count of edits applied.
+func applyEdits(src []byte, edits []lineEdit) ([]byte, int) {
+	if len(edits) == 0 {
+		return src, 0
+	}
+
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].line > edits[j].line
+	})
+
+	lines := strings.Split(string(src), "\n")
+	applied, top := 0, len(lines)+1 // top is the first line of the last applied edit
+	for _, edit := range edits {
+		start, end := edit.line-1, edit.line-1+edit.count
+		if end > len(lines) || edit.line+edit.count > top {
+			// out of file or overlapped
+			continue
+		}
+		if len(edit.err.SourceLines) != 0 && !equalLines(lines[start:end], edit.err.SourceLines) {
+			// file has been changed since linted
+			continue
+		}
+
+		e := edit.err
+		if len(e.SourceLines) == 0 {
+			e.SourceLines = []string{lines[start]}
+		}
+		newLines, ok := e.Fix()
+		if !ok {
+			continue
+		}
+
+		lines = append(lines[:start], append(append([]string{}, newLines...), lines[end:]...)...)
+		top = edit.line
+		applied++
+	}
+
+	return []byte(strings.Join(lines, "\n")), applied
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Patch returns the unified diff of fixes which could be applied by
+// `git apply` or `patch -p1` in dir of Lint.
+func Patch(fixes []FileFix) (string, error) {
+	var buf strings.Builder
+	for _, fix := range fixes {
+		filename := filepath.ToSlash(fix.Filename)
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(fix.Src)),
+			B:        difflib.SplitLines(string(fix.Fixed)),
+			FromFile: "a/" + filename,
+			ToFile:   "b/" + filename,
+			Context:  3,
+		})
+		if err != nil {
+			return "", errors.Wrap(err, "Patch.GetUnifiedDiffString")
+		}
+		buf.WriteString(diff)
+	}
+
+	return buf.String(), nil
+}
+
+// WriteFixes writes fixed content of files in dir.
+func WriteFixes(dir string, fixes []FileFix) error {
+	for _, fix := range fixes {
+		if err := ioutil.WriteFile(filepath.Join(dir, fix.Filename), fix.Fixed, 0644); err != nil {
+			return errors.Wrap(err, "WriteFixes.WriteFile")
+		}
+	}
+	return nil
+}
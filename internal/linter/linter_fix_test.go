@@ -0,0 +1,102 @@
+package linter
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+func Test_applyEdits(t *testing.T) {
+	src := "package a\n\nfunc f() {\n\tfor {\n\t}\n\tx := 1\n}\n"
+
+	tests := []struct {
+		name    string
+		edits   []lineEdit
+		want    string
+		applied int
+	}{
+		{
+			name: "case 0",
+			edits: []lineEdit{
+				{line: 4, count: 1, err: types.Error{
+					SourceLines: []string{"\tfor {"},
+					Replacement: &types.Replacement{Inline: &types.InlineReplacement{StartCol: 1, Length: 3, NewString: "for range ch"}},
+				}},
+				{line: 6, count: 1, err: types.Error{
+					Replacement: &types.Replacement{NeedOnlyDelete: true},
+				}},
+			},
+			want:    "package a\n\nfunc f() {\n\tfor range ch {\n\t}\n}\n",
+			applied: 2,
+		},
+		{
+			// edits are applied from the bottom, outdated line 6 is skipped,
+			// then line 4 overlaps line 5 which is applied
+			name: "case 1",
+			edits: []lineEdit{
+				{line: 4, count: 2, err: types.Error{
+					SourceLines: []string{"\tfor {", "\t}"},
+					Replacement: &types.Replacement{NewLines: []string{"\tselect {}"}},
+				}},
+				{line: 5, count: 1, err: types.Error{
+					Replacement: &types.Replacement{NeedOnlyDelete: true},
+				}},
+				{line: 6, count: 1, err: types.Error{
+					SourceLines: []string{"\ty := 1"},
+					Replacement: &types.Replacement{NeedOnlyDelete: true},
+				}},
+			},
+			want:    "package a\n\nfunc f() {\n\tfor {\n\tx := 1\n}\n",
+			applied: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, applied := applyEdits([]byte(src), tt.edits)
+			if string(got) != tt.want || applied != tt.applied {
+				t.Errorf("applyEdits() = %q, %d, want %q, %d", got, applied, tt.want, tt.applied)
+			}
+		})
+	}
+}
+
+func TestFixes(t *testing.T) {
+	dir := t.TempDir()
+	src := "package a\n\nfunc f()  {\n\tx := 1\n\t_ = x\n}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result := types.LintResult{
+		Scores: []types.Score{
+			{Name: "gofmt", Summaries: []types.FileSummary{{Filename: "a.go", Diff: "@@ -3 +3 @@"}}},
+			{Name: "gocritic", Summaries: []types.FileSummary{{Filename: "a.go", Errors: []types.Error{{
+				LineNumber:  4,
+				SourceLines: []string{"\tx := 1"},
+				Replacement: &types.Replacement{Inline: &types.InlineReplacement{StartCol: 6, Length: 1, NewString: "2"}},
+			}}}}},
+		},
+	}
+
+	fixes, err := Fixes(dir, result)
+	if err != nil {
+		t.Fatalf("Fixes() error = %v", err)
+	}
+	if len(fixes) != 1 || fixes[0].Fixes != 2 {
+		t.Fatalf("Fixes() = %+v, want 2 fixes of a.go", fixes)
+	}
+	if want := "package a\n\nfunc f() {\n\tx := 2\n\t_ = x\n}\n"; string(fixes[0].Fixed) != want {
+		t.Errorf("Fixes() fixed = %q, want %q", fixes[0].Fixed, want)
+	}
+
+	patch, err := Patch(fixes)
+	if err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	if !strings.HasPrefix(patch, "--- a/a.go\n+++ b/a.go\n") || !strings.Contains(patch, "+\tx := 2\n") {
+		t.Errorf("Patch() = %q", patch)
+	}
+}
@@ -1,9 +1,16 @@
 package linter
 
 import (
+	"context"
+	"fmt"
+	"path/filepath"
 	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/yeqown/goreportcard/internal/repository"
 	"github.com/yeqown/goreportcard/internal/types"
 	"github.com/yeqown/log"
 )
@@ -13,162 +20,482 @@ type Context struct {
 	Dir       string   // Dir of repo
 	Filenames []string // Filenames of repo
 	Branch    string   // Branch of repo
+	Excludes  []string // Excludes are globs of paths relative to Dir, such as --exclude of CLI
+	Base      string   // Base ref of diff-aware linting, only issues on lines changed since it are graded
+
+	// Cache stores findings of unchanged packages across runs, packages are
+	// always linted if it's nil.
+	Cache repository.IRepository
+
+	// Ctx carries cancellation and deadline of linting, Lint sets deadline
+	// of the whole report, and each linter has its own deadline derived from
+	// it. context.Background() would be used if it's nil.
+	Ctx context.Context
+
+	report   context.Context      // deadline of the whole report, shared runners use it
+	root     string               // root of repo, Dir is the dir of module in multi-module repo
+	repo     *repoConfig          // `.goreportcard.yml` in root of repo, nil if not exists
+	tests    bool                 // lint test files in the test pass of Lint
+	excluder *excluder            // exclusion engine of all rules
+	excluded []types.ExcludedPath // paths excluded in this module, relative to root of repo
+	golangci *golangciRunner      // shared by all builtin linters
+	analysis *analysisRunner      // shared by all analysis linters
+	scorer   IScorer              // scoring strategy of linters
+	changes  *changeSet           // lines changed since Base, nil if Base is empty
+
+	changedScorer IScorer // scoring strategy of changed files in diff-aware linting
 }
 
+func (c Context) stdContext() context.Context {
+	if c.Ctx == nil {
+		return context.Background()
+	}
+	return c.Ctx
+}
+
+// rootRelative converts filename relative to Dir into relative to root of repo.
+func (c Context) rootRelative(filename string) string {
+	if c.root == "" || c.root == c.Dir {
+		return filename
+	}
+
+	rel, err := filepath.Rel(c.root, filepath.Join(c.Dir, filename))
+	if err != nil {
+		return filename
+	}
+	return rel
+}
+
+// shared returns Context for runners shared by linters, it's bounded by the
+// deadline of report rather than the linter which runs it first.
+func (c Context) shared() Context {
+	if c.report != nil {
+		c.Ctx = c.report
+	}
+	return c
+}
+
+// errNoGoFiles means there is no .go file to lint
+var errNoGoFiles = errors.New("no .go files found")
+
 // Lint executes all checks on the given directory
 //
-// 1. get repo status: @fileCount @lineCount
-// 2. call `golangci-lint` to lint, get errors
-// 3. calc score of each linters
-// 4. return result
+// 1. discover modules of repo, go.work would be honored
+// 2. lint each module in its own dir, see lintModule
+// 3. merge results of modules with per-module breakdown
 func Lint(ctx Context) (result types.LintResult, err error) {
 	log.Debugf("Lint recv params @dir=%s", ctx.Dir)
 
-	filenames, err := visitGoFiles(ctx.Dir)
+	reportCtx, cancel := context.WithTimeout(ctx.stdContext(), types.GetConfig().ReportTimeout())
+	// processes which are still running would be killed
+	defer cancel()
+	ctx.Ctx = reportCtx
+
+	if ctx.repo, err = loadRepoConfig(ctx.Dir, types.GetConfig().RepoConfig); err != nil {
+		return
+	}
+	if ctx.excluder, err = newExcluder(types.GetConfig().SkipDirs, ctx.repo, ctx.Excludes); err != nil {
+		return
+	}
+	if ctx.Base != "" {
+		if ctx.changes, err = gitChanges(ctx.stdContext(), ctx.Dir, ctx.Base); err != nil {
+			return
+		}
+	}
+
+	modules, err := discoverModules(ctx.Dir, ctx.excluder)
+	if err != nil {
+		err = errors.Errorf("could not discover modules: %v", err)
+		return
+	}
+	if len(modules) == 0 || (len(modules) == 1 && modules[0].dir == ".") {
+		// not module aware or only one module in root
+		return lintModule(ctx, nil)
+	}
+
+	return lintModules(ctx, modules)
+}
+
+// lintModule executes all checks on the module in ctx.Dir, files in
+// excludes (dirs of nested modules) are ignored.
+//
+// 1. get repo status: @fileCount @codeSize
+// 2. call `golangci-lint` once with all builtin linters enabled, get errors
+// 3. calc score of each linters
+// 4. lint test files in a separate pass if test linters are configured
+// 5. return result
+func lintModule(ctx Context, excludes []string) (result types.LintResult, err error) {
+	filenames, excluded, err := visitGoFiles(ctx, ctx.Dir)
 	if err != nil {
 		err = errors.Errorf("could not get filenames: %v", err)
 		return
 	}
+	filenames = excludeDirs(filenames, excludes)
+	ctx.excluded = excluded
+	filenames, testFilenames := splitTestFiles(filenames)
 	if len(filenames) == 0 {
-		err = errors.Errorf("no .go files found")
+		err = errNoGoFiles
 		return
 	}
 	ctx.Filenames = filenames
+	ctx.report = ctx.stdContext()
+
+	size, err := codeSize(ctx, filenames)
+	if err != nil {
+		return
+	}
+
+	linters, err := getLinters()
+	if err != nil {
+		return
+	}
+	testLinters := getTestLinters(linters)
 
 	var (
-		linters   = getLinters()
-		chanScore = make(chan types.Score, len(linters))
+		cfg        = types.GetConfig()
+		testScores types.ByWeight
+		testCache  *types.CacheStats
+		wg         sync.WaitGroup
 	)
+	if len(testLinters) != 0 && len(testFilenames) != 0 {
+		testCtx := ctx
+		testCtx.Filenames = testFilenames
+		testCtx.tests = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			testScores, testCache = runLinters(testCtx, testLinters)
+		}()
+	}
+	scores, cache := runLinters(ctx, linters)
+	wg.Wait()
 
-	for _, linter := range linters {
-		go execLinter(ctx, linter, chanScore)
+	excluded, generated := splitGenerated(excluded)
+	result = types.LintResult{
+		Files:       len(filenames),
+		Scores:      scores,
+		Scoring:     newScorer(cfg.Scoring).Name(),
+		TestScores:  testScores,
+		TestFiles:   len(testFilenames),
+		TestsGraded: cfg.Tests.Graded,
+		Excluded:    excluded,
+		Generated:   generated,
+		Size:        size,
+		Cache:       mergeCache(cache, testCache),
 	}
+	result.Dependencies = takeDependencies(scores)
+	result.Vulnerabilities = takeVulnerabilities(scores)
+	result.Security = securityScore(scores, securityChecks(linters))
+	result.Issues, result.Suppressed = countIssues(scores)
+	testIssues, testSuppressed := countIssues(testScores)
+	result.TestIssues = testIssues
+	result.Suppressed += testSuppressed
+	if ctx.changes != nil {
+		result.Base = ctx.changes.base
+		result.ChangedFiles = len(ctx.changedOnly().Filenames)
+		result.ExistingIssues = countExisting(scores) + countExisting(testScores)
+	}
+
+	graded := scores
+	if cfg.Tests.Graded {
+		graded = append(append(make(types.ByWeight, 0, len(scores)+len(testScores)), scores...), testScores...)
+	}
+	result.Average = weightedAverage(graded)
+	result.Grade = types.GradeFromPercentage(result.Average * 100)
+
+	return
+}
 
+// runLinters executes linters concurrently on ctx.Filenames, and returns
+// scores sorted by weight, and stats of cache if ctx.Cache is set.
+func runLinters(ctx Context, linters []ILinter) (types.ByWeight, *types.CacheStats) {
 	var (
-		total, totalWeight float64
-		issuesCnt          int
-		n                  = len(linters)
-		scores             = make(types.ByWeight, 0, 64)
+		chanScore = make(chan types.Score, len(linters))
+		builtins  = make([]builtin, 0, len(linters))
+		analyzers = make([]analyzer, 0, len(linters))
 	)
 
-	// calc grade and score, then save into `types.CheckResult`
-	for i := 0; i < n; i++ {
-		score := <-chanScore
-		scores = append(scores, score)
-
-		total += score.Percentage * score.Weight
-		totalWeight += score.Weight
-		for _, summary := range score.Summaries {
-			issuesCnt += len(summary.Errors)
+	for _, linter := range linters {
+		switch l := linter.(type) {
+		case builtin:
+			builtins = append(builtins, l)
+		case analyzer:
+			analyzers = append(analyzers, l)
 		}
 	}
+	ctx.golangci = newGolangciRunner(builtins, ctx.tests)
+	ctx.analysis = newAnalysisRunner(analyzers, ctx.tests)
+	ctx.scorer = newScorer(types.GetConfig().Scoring)
+	if ctx.changes != nil {
+		ctx.changedScorer = newScorer(types.GetConfig().Scoring)
+	}
+
+	cache, err := newLintCache(ctx)
+	if err != nil {
+		log.Warnf("runLinters could not use cache, err=%v", err)
+		cache = nil
+	}
+	if cache != nil {
+		linters = withCache(ctx, cache, linters)
+	}
+
+	for _, linter := range linters {
+		go execLinter(ctx, linter, types.GetConfig().TimeoutOf(linter.Name()), chanScore)
+	}
+
+	scores := make(types.ByWeight, 0, len(linters))
+	for i := 0; i < len(linters); i++ {
+		scores = append(scores, <-chanScore)
+	}
 	close(chanScore)
-	total /= totalWeight
 	sort.Sort(scores)
 
-	result = types.LintResult{
-		Files:   len(filenames),
-		Issues:  issuesCnt,
-		Average: total,
-		Scores:  scores,
-		Grade:   types.GradeFromPercentage(total * 100),
+	return scores, cache.stats()
+}
+
+// countIssues counts issues and suppressed issues of scores
+func countIssues(scores []types.Score) (issues, suppressed int) {
+	for _, score := range scores {
+		for _, summary := range score.Summaries {
+			issues += len(summary.Errors)
+		}
+		suppressed += len(score.Suppressed)
 	}
+	return issues, suppressed
+}
 
-	return
+// countExisting counts issues on unchanged lines of scores in diff-aware linting
+func countExisting(scores []types.Score) (existing int) {
+	for _, score := range scores {
+		existing += score.Existing
+	}
+	return existing
 }
 
-// https://golangci-lint.run/usage/linters/
-//
-// govet - Vet examines Go source code and reports suspicious constructs,
-//         such as Printf calls whose arguments do not align with the format string
-// errcheck - Errcheck is a program for checking for unchecked errors in go programs.
-//         These unchecked errors can be critical bugs in some cases
-// staticcheck - Staticcheck is a go vet on steroids, applying a ton of static analysis checks
-// unused - Scores Go code for unused constants, variables, functions and types
-// gosimple - Linter for Go source code that specializes in simplifying a code
-// structcheck - Finds unused struct fields
-// varcheck - Finds unused global variables and constants
-// ineffassign - Detects when assignments to existing variables are not used
-// deadcode - Finds unused code
-// typecheck - Like the front-end of a Go compiler, parses and type-checks Go code
-//
+// splitTestFiles splits _test.go files from filenames
+func splitTestFiles(filenames []string) (files, tests []string) {
+	files = make([]string, 0, len(filenames))
+	for _, filename := range filenames {
+		if strings.HasSuffix(filename, "_test.go") {
+			tests = append(tests, filename)
+			continue
+		}
+		files = append(files, filename)
+	}
+	return files, tests
+}
+
+// weightedAverage is sum(percentage * weight) / sum(weight) of scores,
+// timed-out scores are ignored.
+func weightedAverage(scores []types.Score) float64 {
+	var total, totalWeight float64
+	for _, score := range scores {
+		if score.State == types.ScoreTimeout {
+			// unknown percentage should not be counted as 0
+			continue
+		}
+		total += score.Percentage * score.Weight
+		totalWeight += score.Weight
+	}
+	if totalWeight == 0 {
+		return 0
+	}
+
+	return total / totalWeight
+}
 
-// getLinters . load all linters to run
+// _natives are constructors of native linters, key is the name of linter.
+var _natives = map[string]func(opt *types.LinterOption) (ILinter, error){
+	"gofmt":      newGofmt,
+	"complexity": newComplexity,
+	"license":    newLicense,
+	"coverage":   newCoverage,
+	"deps":       newDeps,
+	"vuln":       newVuln,
+	"doc":        newDoc,
+	"gosec":      newGosec,
+	"duplicate":  newDuplicate,
+}
+
+// getLinters . load all enabled linters to run from config
 // linters: https://golangci-lint.run/usage/linters/
-func getLinters() []ILinter {
-	return []ILinter{
-		builtin{
-			name: "govet", weight: .30,
-			desc: "Vet examines Go source code and reports suspicious constructs, such as Printf calls whose arguments do not align with the format string.",
-		}, // govet
-		builtin{
-			name: "errcheck", weight: .10,
-			desc: "Errcheck is a program for checking for unchecked errors in go programs. These unchecked errors can be critical bugs in some cases.",
-		}, // errcheck
-		builtin{
-			name: "ineffassign", weight: .05,
-			desc: "Detects when assignments to existing variables are not used.",
-		}, // ineffassign
-		builtin{
-			name: "deadcode", weight: .05,
-			desc: "Finds unused code",
-		}, // deadcode
-		builtin{
-			name: "gosimple", weight: .05,
-			desc: "Linter for Go source code that specializes in simplifying a code.",
-		}, // gosimple
-		builtin{
-			name: "staticcheck", weight: .05,
-			desc: "Staticcheck is a go vet on steroids, applying a ton of static analysis checks.",
-		}, // staticcheck
-		builtin{
-			name: "structcheck", weight: .05,
-			desc: "Finds unused struct fields.",
-		}, // structcheck
-		builtin{
-			name: "unused", weight: .10,
-			desc: "Scores Go code for unused constants, variables, functions and types.",
-		}, // unused
-		builtin{
-			name: "varcheck", weight: .05,
-			desc: "Finds unused global variables and constants.",
-		}, // varcheck
-		builtin{
-			name: "typecheck", weight: .05,
-			desc: "Like the front-end of a Go compiler, parses and type-checks Go codes.",
-		}, // typecheck
-		builtin{
-			name: "funlen", weight: .10,
-			desc: "Tool for detection of long functions.",
-		}, // funlen
-		builtin{
-			name: "lll", weight: .10,
-			desc: "Reports long lines.",
-		}, // lll
-		builtin{
-			name: "nestif", weight: .15,
-			desc: "Reports deeply nested if statements.",
-		}, // nestif
-	}
-}
-
-// execLinter exec linter.Execute and send types.Score by `chanScore`
-func execLinter(ctx Context, linter ILinter, chanScore chan<- types.Score) {
-	var errMsg string
-	p, summaries, err := linter.Execute(ctx)
-	if err != nil {
-		log.Errorf("Lint run linter=%s failed, err=%v", linter.Name(), err)
-		errMsg = err.Error()
+func getLinters() ([]ILinter, error) {
+	opts := types.GetConfig().Linters
+	linters := make([]ILinter, 0, len(opts))
+	for _, opt := range opts {
+		if opt.Disabled {
+			continue
+		}
+
+		switch opt.Type {
+		case types.AnalysisLinter:
+			a, err := newAnalyzer(opt)
+			if err != nil {
+				return nil, err
+			}
+			linters = append(linters, a)
+		case types.PluginLinter:
+			p, err := newPlugin(opt)
+			if err != nil {
+				return nil, err
+			}
+			linters = append(linters, p)
+		case types.NativeLinter:
+			newNative, ok := _natives[opt.Name]
+			if !ok {
+				return nil, errors.Errorf("no such native linter %q", opt.Name)
+			}
+			l, err := newNative(opt)
+			if err != nil {
+				return nil, err
+			}
+			linters = append(linters, l)
+		default:
+			linters = append(linters, builtin{
+				name:     opt.Name,
+				desc:     opt.Desc,
+				weight:   opt.Weight,
+				settings: opt.Settings,
+			})
+		}
 	}
 
-	// send score to channel
-	score := types.Score{
-		Name:       linter.Name(),
-		Desc:       linter.Description(),
-		Summaries:  summaries,
-		Weight:     linter.Weight(),
-		Percentage: p,
-		Error:      errMsg,
+	return linters, nil
+}
+
+// getTestLinters returns linters which lint test files, see types.TestsOption.
+func getTestLinters(linters []ILinter) []ILinter {
+	names := types.GetConfig().Tests.Linters
+	testLinters := make([]ILinter, 0, len(names))
+	for _, linter := range linters {
+		for _, name := range names {
+			if linter.Name() == name {
+				testLinters = append(testLinters, linter)
+				break
+			}
+		}
 	}
+
+	return testLinters
+}
+
+// execLinter exec linter.Execute within timeout and send types.Score by `chanScore`,
+// the score would be sent at the deadline even if the linter is still running.
+func execLinter(ctx Context, linter ILinter, timeout time.Duration, chanScore chan<- types.Score) {
+	linterCtx, cancel := context.WithTimeout(ctx.stdContext(), timeout)
+	defer cancel()
+	ctx.Ctx = linterCtx
+
+	done := make(chan types.Score, 1)
+	go func() {
+		score := newScore(linter)
+		score.State = types.ScoreOK
+
+		var err error
+		if detail, ok := linter.(IDetailLinter); ok {
+			err = detail.ExecuteDetail(ctx, &score)
+		} else {
+			score.Percentage, score.Summaries, err = linter.Execute(ctx)
+		}
+		if err != nil {
+			log.Errorf("Lint run linter=%s failed, err=%v", linter.Name(), err)
+			score.Error = err.Error()
+			score.State = types.ScoreFailed
+		}
+
+		done <- score
+	}()
+
+	var (
+		score types.Score
+		err   error
+	)
+	select {
+	case score = <-done:
+	case <-linterCtx.Done():
+		// the linter is still running, but its result is useless
+		score = newScore(linter)
+		score.State = types.ScoreFailed
+		score.Error = linterCtx.Err().Error()
+	}
+
+	if score.State == types.ScoreOK && (ctx.filter(&score) || ctx.changes != nil) && rescorable(linter) {
+		// percentage of excluded and suppressed issues should be recalculated,
+		// and only changed files are graded in diff-aware linting
+		if score.Percentage, err = rescore(ctx.changedOnly(), linter, score.Summaries); err != nil {
+			score.Error = err.Error()
+			score.State = types.ScoreFailed
+		}
+	}
+	score.Weight = ctx.repo.weight(score.Name, score.Weight)
+
+	// failed because of being killed at deadline is also timeout
+	if score.State == types.ScoreFailed && linterCtx.Err() == context.DeadlineExceeded {
+		log.Errorf("Lint run linter=%s timeout after %s", linter.Name(), timeout)
+		score = newScore(linter)
+		score.State = types.ScoreTimeout
+		score.Error = fmt.Sprintf("timeout after %s", timeout)
+	}
+
+	// send score to channel
 	chanScore <- score
 }
+
+func newScore(linter ILinter) types.Score {
+	return types.Score{
+		Name:   linter.Name(),
+		Desc:   linter.Description(),
+		Weight: linter.Weight(),
+	}
+}
+
+// rescorable reports whether percentage of linter is calculated from its
+// summaries, see rescore. Native linters which have their own measures, such
+// as complexity and coverage, are not rescorable.
+func rescorable(linter ILinter) bool {
+	switch l := linter.(type) {
+	case builtin, analyzer, gofmt, plugin, gosec, vuln:
+		return true
+	case cachedLinter:
+		return rescorable(l.ILinter)
+	}
+	return false
+}
+
+// rescore recalculates percentage of rescorable linter from summaries, gosec
+// weighs issues by severity and confidence, vuln takes the worst finding, and
+// the others use calcPercentage.
+func rescore(ctx Context, linter ILinter, summaries []types.FileSummary) (float64, error) {
+	switch l := linter.(type) {
+	case gosec:
+		return gosecPercentage(ctx, summaries), nil
+	case vuln:
+		return vulnPercentage(summaries), nil
+	case cachedLinter:
+		return rescore(ctx, l.ILinter, summaries)
+	}
+	return calcPercentage(ctx, summaries)
+}
+
+// filter removes issues which should not be reported from score: issues
+// out of test files in the test pass, issues of excluded paths, issues
+// suppressed by `.goreportcard.yml` and issues on unchanged lines in diff-aware
+// linting. It reports whether summaries are changed.
+func (c Context) filter(score *types.Score) bool {
+	kept := score.Summaries[:0]
+	for _, summary := range score.Summaries {
+		// golangci-lint and analyzers also report issues of non-test files
+		if c.tests && !strings.HasSuffix(summary.Filename, "_test.go") {
+			continue
+		}
+		if c.excluder.match(c.rootRelative(summary.Filename)) != nil {
+			continue
+		}
+		kept = append(kept, summary)
+	}
+	changed := len(kept) != len(score.Summaries)
+	score.Summaries = kept
+
+	suppressed := c.repo.filter(c, score)
+	return c.filterChanges(score) || suppressed || changed
+}
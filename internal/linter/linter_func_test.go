@@ -0,0 +1,111 @@
+package linter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+// sleepLinter sleeps and ignores the context
+type sleepLinter struct {
+	d time.Duration
+}
+
+func (l sleepLinter) Name() string        { return "sleep" }
+func (l sleepLinter) Description() string { return "" }
+func (l sleepLinter) Weight() float64     { return 1 }
+
+func (l sleepLinter) Execute(ctx Context) (float64, []types.FileSummary, error) {
+	time.Sleep(l.d)
+	return 1, nil, nil
+}
+
+func Test_execLinter(t *testing.T) {
+	tests := []struct {
+		name      string
+		linter    ILinter
+		timeout   time.Duration
+		wantState types.ScoreState
+	}{
+		{
+			name:      "case 0",
+			linter:    sleepLinter{d: time.Millisecond},
+			timeout:   time.Second,
+			wantState: types.ScoreOK,
+		},
+		{
+			name:      "case 1",
+			linter:    sleepLinter{d: time.Second},
+			timeout:   10 * time.Millisecond,
+			wantState: types.ScoreTimeout,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chanScore := make(chan types.Score, 1)
+			go execLinter(Context{}, tt.linter, tt.timeout, chanScore)
+
+			select {
+			case score := <-chanScore:
+				if score.State != tt.wantState {
+					t.Errorf("execLinter() state = %v, want %v", score.State, tt.wantState)
+				}
+			case <-time.After(tt.timeout + 500*time.Millisecond):
+				t.Fatalf("execLinter() did not send score before deadline")
+			}
+		})
+	}
+}
+
+func Test_splitTestFiles(t *testing.T) {
+	files, tests := splitTestFiles([]string{"a.go", "a_test.go", "b.go", "test.go", "b_test.go"})
+
+	if want := []string{"a.go", "b.go", "test.go"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("splitTestFiles() files = %v, want %v", files, want)
+	}
+	if want := []string{"a_test.go", "b_test.go"}; !reflect.DeepEqual(tests, want) {
+		t.Errorf("splitTestFiles() tests = %v, want %v", tests, want)
+	}
+}
+
+func TestContext_filter(t *testing.T) {
+	tests := []struct {
+		name        string
+		tests       bool
+		wantChanged bool
+		wantFiles   int
+	}{
+		{
+			name:        "case 0",
+			tests:       false,
+			wantChanged: false,
+			wantFiles:   2,
+		},
+		{
+			name:        "case 1",
+			tests:       true,
+			wantChanged: true,
+			wantFiles:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := types.Score{
+				Summaries: []types.FileSummary{
+					{Filename: "a.go", Errors: []types.Error{{LineNumber: 1}}},
+					{Filename: "a_test.go", Errors: []types.Error{{LineNumber: 1}}},
+				},
+			}
+
+			changed := Context{tests: tt.tests}.filter(&score)
+			if changed != tt.wantChanged {
+				t.Errorf("filter() = %v, want %v", changed, tt.wantChanged)
+			}
+			if len(score.Summaries) != tt.wantFiles {
+				t.Errorf("filter() summaries = %v, want %d files", score.Summaries, tt.wantFiles)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package linter
+
+import (
+	"bytes"
+	"go/format"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/yeqown/goreportcard/internal/types"
+	"github.com/yeqown/log"
+	"golang.org/x/tools/imports"
+)
+
+var _ ILinter = gofmt{}
+
+// gofmt formats files with go/format, and reports every unformatted file
+// with the unified diff of changes. If goimports is set, imports would be
+// also grouped and sorted like goimports does.
+type gofmt struct {
+	name   string  // linter's name
+	desc   string  // linter's desc
+	weight float64 // linter's weight
+
+	goimports bool // format with goimports rules
+}
+
+func newGofmt(opt *types.LinterOption) (ILinter, error) {
+	g := gofmt{
+		name:   opt.Name,
+		desc:   opt.Desc,
+		weight: opt.Weight,
+	}
+
+	var err error
+	if g.goimports, err = settingBool(opt.Settings, "goimports", false); err != nil {
+		return nil, errors.Wrap(err, "gofmt")
+	}
+
+	return g, nil
+}
+
+func (g gofmt) Name() string {
+	return g.name
+}
+
+func (g gofmt) Description() string {
+	return g.desc
+}
+
+func (g gofmt) Weight() float64 {
+	return g.weight
+}
+
+func (g gofmt) Execute(ctx Context) (float64, []types.FileSummary, error) {
+	summaries := make([]types.FileSummary, 0, 8)
+	for _, path := range ctx.Filenames {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return 0, nil, errors.Wrap(err, "gofmt.ReadFile")
+		}
+
+		formatted, err := g.format(path, src)
+		if err != nil {
+			// file could not be parsed, typecheck would report it
+			log.Warnf("gofmt failed to format file=%s, err=%v", path, err)
+			continue
+		}
+		if bytes.Equal(src, formatted) {
+			continue
+		}
+
+		filename, _ := filepath.Rel(ctx.Dir, path)
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(src)),
+			B:        difflib.SplitLines(string(formatted)),
+			FromFile: filename,
+			ToFile:   filename + " (formatted)",
+			Context:  3,
+		})
+		if err != nil {
+			return 0, nil, errors.Wrap(err, "gofmt.GetUnifiedDiffString")
+		}
+
+		summaries = append(summaries, types.FileSummary{
+			Filename: filename,
+			FileURL:  assembleRemoteFileURI(ctx.Dir, ctx.Branch, filename),
+			Errors: []types.Error{{
+				LineNumber:  firstChangedLine(diff),
+				Rule:        g.name,
+				ErrorString: g.message(),
+			}},
+			Diff: diff,
+		})
+	}
+
+	p, err := calcPercentage(ctx, summaries)
+	return p, summaries, err
+}
+
+func (g gofmt) format(path string, src []byte) ([]byte, error) {
+	if g.goimports {
+		// FormatOnly: only group and sort imports, never add or remove them
+		return imports.Process(path, src, &imports.Options{
+			Comments:   true,
+			TabIndent:  true,
+			TabWidth:   8,
+			FormatOnly: true,
+		})
+	}
+
+	return format.Source(src)
+}
+
+func (g gofmt) message() string {
+	if g.goimports {
+		return "file is not goimports-ed"
+	}
+	return "file is not gofmt-ed"
+}
+
+// hunkHeader matches "@@ -l,s +l,s @@" of unified diff
+var hunkHeader = regexp.MustCompile(`(?m)^@@ -(\d+)`)
+
+// firstChangedLine returns the start line of the first hunk in unified diff,
+// the context lines are included.
+func firstChangedLine(diff string) int {
+	m := hunkHeader.FindStringSubmatch(diff)
+	if m == nil {
+		return 1
+	}
+
+	line, _ := strconv.Atoi(m[1])
+	if line == 0 {
+		line = 1
+	}
+	return line
+}
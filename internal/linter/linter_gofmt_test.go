@@ -0,0 +1,72 @@
+package linter
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+func Test_gofmt_Execute(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.go": "package a\n\nimport (\n\t\"os\"\n\t\"fmt\"\n)\n\nfunc  A() { fmt.Println(os.Args) }\n",
+		"b.go": "package a\n\nfunc B() {}\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	filenames, _, err := visitGoFiles(Context{Dir: dir}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		goimports bool
+		wantDiff  []string
+	}{
+		{
+			name:      "case 0",
+			goimports: false,
+			wantDiff:  []string{"-func  A()", "+func A()"},
+		},
+		{
+			name:      "case 1",
+			goimports: true,
+			wantDiff:  []string{"+\t\"fmt\"", "-\t\"fmt\"", "+func A()"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := newGofmt(&types.LinterOption{
+				Name:     "gofmt",
+				Weight:   1,
+				Settings: map[string]interface{}{"goimports": tt.goimports},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p, summaries, err := g.Execute(Context{Dir: dir, Filenames: filenames})
+			if err != nil {
+				t.Fatalf("gofmt.Execute() error = %v", err)
+			}
+			if p != 0.5 {
+				t.Errorf("gofmt.Execute() percentage = %v, want %v", p, 0.5)
+			}
+			if len(summaries) != 1 || summaries[0].Filename != "a.go" {
+				t.Fatalf("gofmt.Execute() summaries = %+v, want only a.go", summaries)
+			}
+			for _, want := range tt.wantDiff {
+				if !strings.Contains(summaries[0].Diff, want) {
+					t.Errorf("gofmt.Execute() diff = %s, want contains %q", summaries[0].Diff, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package linter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yeqown/goreportcard/internal/types"
+	"github.com/yeqown/log"
+)
+
+var _ ILinter = gosec{}
+
+var (
+	// _gosecSeverities maps severity of gosec onto severity of types.Error
+	_gosecSeverities = map[string]string{
+		"HIGH":   "error",
+		"MEDIUM": "warning",
+		"LOW":    "info",
+	}
+
+	// _gosecWeights are penalties of issues by severity and confidence of
+	// types.Error, a high-severity issue costs as much as a whole file in
+	// other checks. Unknown ones are treated as the highest.
+	_gosecWeights = map[string]float64{
+		"error":   1,
+		"warning": .4,
+		"info":    .1,
+	}
+	_gosecConfidences = map[string]float64{
+		"high":   1,
+		"medium": .7,
+		"low":    .4,
+	}
+)
+
+// gosec runs securego/gosec in the repo, issues keep severity and confidence
+// of gosec. The percentage is 1 - sum(severity * confidence) / files, so
+// high-severity issues count much more than issues of style checks. It's
+// recalculated by gosecPercentage after issues are filtered.
+type gosec struct {
+	name   string  // linter's name
+	desc   string  // linter's desc
+	weight float64 // linter's weight
+
+	command string // gosec executable
+}
+
+// gosecReport is the JSON output of gosec, only fields used are decoded.
+type gosecReport struct {
+	Issues []gosecIssue `json:"Issues"`
+}
+
+type gosecIssue struct {
+	Severity   string `json:"severity"`
+	Confidence string `json:"confidence"`
+	CWE        struct {
+		ID string `json:"id"`
+	} `json:"cwe"`
+	RuleID  string `json:"rule_id"`
+	Details string `json:"details"`
+	File    string `json:"file"`
+	Line    string `json:"line"`   // such as "12" or "12-14"
+	Column  string `json:"column"` // such as "5"
+}
+
+func newGosec(opt *types.LinterOption) (ILinter, error) {
+	command, err := settingString(opt.Settings, "command", "gosec")
+	if err != nil {
+		return nil, errors.Wrap(err, "gosec")
+	}
+
+	return gosec{
+		name:    opt.Name,
+		desc:    opt.Desc,
+		weight:  opt.Weight,
+		command: command,
+	}, nil
+}
+
+func (g gosec) Name() string {
+	return g.name
+}
+
+func (g gosec) Description() string {
+	return g.desc
+}
+
+func (g gosec) Weight() float64 {
+	return g.weight
+}
+
+func (g gosec) Execute(ctx Context) (float64, []types.FileSummary, error) {
+	dir, err := filepath.Abs(ctx.Dir)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "gosec.Abs")
+	}
+
+	args := []string{"-fmt=json", "-quiet", "-no-fail"}
+	if ctx.tests {
+		args = append(args, "-tests")
+	}
+	cmd := exec.CommandContext(ctx.stdContext(), g.command, append(args, "./...")...)
+	cmd.Dir = dir
+	killProcessGroup(cmd)
+	cmd.WaitDelay = time.Second
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		if ctxErr := ctx.stdContext().Err(); ctxErr != nil {
+			return 0, nil, errors.Wrap(ctxErr, "gosec")
+		}
+		return 0, nil, errors.Wrapf(err, "gosec: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	summaries, err := parseGosecOutput(ctx, dir, stdout.Bytes())
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return gosecPercentage(ctx, summaries), summaries, nil
+}
+
+// gosecPercentage is 1 - sum(severity * confidence) / files of issues in
+// summaries, it's 0 at least.
+func gosecPercentage(ctx Context, summaries []types.FileSummary) float64 {
+	if len(ctx.Filenames) == 0 {
+		return 1
+	}
+
+	var penalty float64
+	for _, summary := range summaries {
+		for _, e := range summary.Errors {
+			weight, ok := _gosecWeights[e.Severity]
+			if !ok {
+				weight = _gosecWeights["error"]
+			}
+			factor, ok := _gosecConfidences[e.Confidence]
+			if !ok {
+				factor = _gosecConfidences["high"]
+			}
+			penalty += weight * factor
+		}
+	}
+
+	return math.Max(0, 1-penalty/float64(len(ctx.Filenames)))
+}
+
+// parseGosecOutput converts issues of gosec into summaries, issues of files
+// which are not in ctx.Filenames are ignored.
+func parseGosecOutput(ctx Context, dir string, out []byte) ([]types.FileSummary, error) {
+	var report gosecReport
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, errors.Wrap(err, "gosec: invalid output")
+	}
+
+	files := make(map[string]struct{}, len(ctx.Filenames))
+	for _, filename := range ctx.Filenames {
+		rel, err := filepath.Rel(ctx.Dir, filename)
+		if err != nil {
+			continue
+		}
+		files[filepath.ToSlash(rel)] = struct{}{}
+	}
+
+	collector := newSummaryCollector(ctx)
+	for _, issue := range report.Issues {
+		filename := issue.File
+		if filepath.IsAbs(filename) {
+			rel, err := filepath.Rel(dir, filename)
+			if err != nil {
+				continue
+			}
+			filename = rel
+		}
+		filename = filepath.ToSlash(filename)
+		if _, ok := files[filename]; !ok {
+			log.Debugf("gosec ignored issue of file=%s which is not linted", issue.File)
+			continue
+		}
+
+		line, _ := strconv.Atoi(strings.SplitN(issue.Line, "-", 2)[0])
+		column, _ := strconv.Atoi(issue.Column)
+		msg := issue.RuleID + ": " + issue.Details
+		if issue.CWE.ID != "" {
+			msg += fmt.Sprintf(" (CWE-%s)", issue.CWE.ID)
+		}
+		collector.add(filename, types.Error{
+			LineNumber:  line,
+			Column:      column,
+			Rule:        issue.RuleID,
+			Severity:    _gosecSeverities[strings.ToUpper(issue.Severity)],
+			Confidence:  strings.ToLower(issue.Confidence),
+			ErrorString: msg,
+		})
+	}
+
+	return collector.summaries(), nil
+}
+
+// securityChecks returns names of linters which are security checks.
+func securityChecks(linters []ILinter) []string {
+	var names []string
+	for _, linter := range linters {
+		switch linter.(type) {
+		case gosec, vuln:
+			names = append(names, linter.Name())
+		}
+	}
+	return names
+}
+
+// securityScore is the sub-grade of security checks in scores, nil if no
+// security check is enabled.
+func securityScore(scores []types.Score, checks []string) *types.SecurityScore {
+	if len(checks) == 0 {
+		return nil
+	}
+
+	names := make(map[string]struct{}, len(checks))
+	for _, name := range checks {
+		names[name] = struct{}{}
+	}
+	security := make([]types.Score, 0, len(checks))
+	for _, score := range scores {
+		if _, ok := names[score.Name]; ok {
+			security = append(security, score)
+		}
+	}
+	if len(security) == 0 {
+		return nil
+	}
+
+	s := &types.SecurityScore{
+		Checks:     checks,
+		Percentage: weightedAverage(security),
+	}
+	s.Grade = types.GradeFromPercentage(s.Percentage * 100)
+	s.Issues, _ = countIssues(security)
+	return s
+}
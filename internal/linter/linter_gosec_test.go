@@ -0,0 +1,108 @@
+package linter
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+func Test_gosec_Execute(t *testing.T) {
+	dir := "testdata/gosec"
+	command, _ := filepath.Abs(filepath.Join(dir, "gosec.sh"))
+	l, err := newGosec(&types.LinterOption{
+		Name:     "gosec",
+		Settings: map[string]interface{}{"command": command},
+	})
+	if err != nil {
+		t.Fatalf("newGosec() error = %v", err)
+	}
+
+	ctx := Context{
+		Dir:       dir,
+		Filenames: []string{filepath.Join(dir, "main.go"), filepath.Join(dir, "util.go")},
+		Branch:    types.MasterBranch,
+	}
+	percentage, summaries, err := l.Execute(ctx)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	// 1 * .7 + .1 * 1 of 2 files, issue of other.go is not counted
+	if math.Abs(percentage-.6) > 1e-9 {
+		t.Errorf("Execute() percentage = %v, want 0.6", percentage)
+	}
+
+	var got []string
+	for _, summary := range summaries {
+		for _, e := range summary.Errors {
+			got = append(got, summary.Filename+":"+e.Rule+":"+e.Severity+":"+e.Confidence)
+		}
+	}
+	want := []string{"main.go:G101:error:medium", "main.go:G104:info:high"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Execute() issues = %v, want %v", got, want)
+	}
+	if e := summaries[0].Errors[1]; e.LineNumber != 7 || e.Column != 2 {
+		t.Errorf("Execute() position = %d:%d, want 7:2", e.LineNumber, e.Column)
+	}
+}
+
+func Test_securityScore(t *testing.T) {
+	scores := []types.Score{
+		{Name: "gofmt", Percentage: .2, Weight: 1},
+		{Name: "gosec", Percentage: .5, Weight: 1,
+			Summaries: []types.FileSummary{{Filename: "a.go", Errors: []types.Error{{}, {}}}}},
+		{Name: "vuln", Percentage: 1, Weight: 3},
+	}
+
+	tests := []struct {
+		name   string
+		checks []string
+		want   *types.SecurityScore
+	}{
+		{name: "case 1", checks: nil, want: nil},
+		{name: "case 2", checks: []string{"missing"}, want: nil},
+		{name: "case 3", checks: []string{"gosec"},
+			want: &types.SecurityScore{Checks: []string{"gosec"}, Percentage: .5,
+				Grade: types.GradeFromPercentage(50), Issues: 2}},
+		{name: "case 4", checks: []string{"gosec", "vuln"},
+			want: &types.SecurityScore{Checks: []string{"gosec", "vuln"}, Percentage: .875,
+				Grade: types.GradeFromPercentage(87.5), Issues: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := securityScore(scores, tt.checks); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("securityScore() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rescore_gosec(t *testing.T) {
+	ctx := Context{Dir: ".", Filenames: []string{"a.go", "b.go"}}
+	issue := func(severity, confidence string) types.FileSummary {
+		return types.FileSummary{Filename: "a.go", Errors: []types.Error{{Severity: severity, Confidence: confidence}}}
+	}
+
+	tests := []struct {
+		name      string
+		summaries []types.FileSummary
+		want      float64
+	}{
+		{name: "case 1", summaries: nil, want: 1},
+		{name: "case 2", summaries: []types.FileSummary{issue("error", "medium")}, want: .65},
+		{name: "case 3", summaries: []types.FileSummary{issue("info", "high")}, want: .95},
+		{name: "case 4", summaries: []types.FileSummary{issue("error", "high"), issue("error", "high"), issue("", "")}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rescore(ctx, gosec{}, tt.summaries)
+			if err != nil || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("rescore() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
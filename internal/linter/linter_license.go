@@ -0,0 +1,276 @@
+package linter
+
+import (
+	"embed"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/yeqown/goreportcard/internal/types"
+	"github.com/yeqown/log"
+)
+
+// _licenseCorpus contains license texts named by SPDX identifier,
+// long licenses only keep the beginning part which is enough to identify.
+//
+//go:embed licenses/*.txt
+var _licenseCorpus embed.FS
+
+var (
+	// _licenseFilePrefixes of license files in the root of repo, in lower case
+	_licenseFilePrefixes = []string{"license", "licence", "copying", "unlicense"}
+
+	// _licenseHeaders are phrases of license headers in source files
+	_licenseHeaders = []struct {
+		phrase string
+		spdx   string
+	}{
+		{"licensed under the apache license, version 2.0", "Apache-2.0"},
+		{"governed by a bsd-style license", "BSD-3-Clause"},
+		{"governed by an mit-style license", "MIT"},
+		{"licensed under the mit license", "MIT"},
+		{"gnu affero general public license", "AGPL-3.0"},
+		{"gnu lesser general public license", "LGPL-3.0"},
+		{"gnu general public license as published by the free software foundation, either version 3", "GPL-3.0"},
+		{"gnu general public license as published by the free software foundation; either version 2", "GPL-2.0"},
+		{"mozilla public license, v. 2.0", "MPL-2.0"},
+	}
+
+	_spdxHeader = regexp.MustCompile(`SPDX-License-Identifier:\s*([A-Za-z0-9.+-]+)`)
+)
+
+// licenseMatchThreshold is the minimum coverage of license template
+// to be recognized as the license.
+const licenseMatchThreshold = 0.75
+
+var _ IDetailLinter = license{}
+
+// license finds the license file in the root of repo and identifies it with
+// embedded license corpus, then checks license headers of files disagree
+// with the root license or not.
+type license struct {
+	name   string  // linter's name
+	desc   string  // linter's desc
+	weight float64 // linter's weight
+
+	templates map[string]map[string]struct{} // map[spdx]shingles
+}
+
+func newLicense(opt *types.LinterOption) (ILinter, error) {
+	entries, err := _licenseCorpus.ReadDir("licenses")
+	if err != nil {
+		return nil, errors.Wrap(err, "license.ReadDir")
+	}
+
+	l := license{
+		name:      opt.Name,
+		desc:      opt.Desc,
+		weight:    opt.Weight,
+		templates: make(map[string]map[string]struct{}, len(entries)),
+	}
+	for _, entry := range entries {
+		data, err := _licenseCorpus.ReadFile("licenses/" + entry.Name())
+		if err != nil {
+			return nil, errors.Wrap(err, "license.ReadFile")
+		}
+		spdx := strings.TrimSuffix(entry.Name(), ".txt")
+		l.templates[spdx] = shingles(string(data))
+	}
+
+	return l, nil
+}
+
+func (l license) Name() string {
+	return l.name
+}
+
+func (l license) Description() string {
+	return l.desc
+}
+
+func (l license) Weight() float64 {
+	return l.weight
+}
+
+func (l license) Execute(ctx Context) (float64, []types.FileSummary, error) {
+	score := types.Score{}
+	err := l.ExecuteDetail(ctx, &score)
+	return score.Percentage, score.Summaries, err
+}
+
+func (l license) ExecuteDetail(ctx Context, score *types.Score) error {
+	info, err := l.detect(ctx.Dir)
+	if err != nil {
+		return err
+	}
+	if info.File == "" && ctx.root != "" && ctx.root != ctx.Dir {
+		// module in sub dir shares the license in the root of repo
+		if info, err = l.detect(ctx.root); err != nil {
+			return err
+		}
+	}
+	score.License = info
+
+	if info.File == "" {
+		// no license at all
+		score.Percentage = 0
+		return nil
+	}
+	if info.SPDX == "" {
+		// there is a license file, but not recognized
+		score.Percentage = .5
+		return nil
+	}
+
+	collector := newSummaryCollector(ctx)
+	fset := token.NewFileSet()
+	conflicts := 0
+	for _, path := range ctx.Filenames {
+		f, err := parser.ParseFile(fset, path, nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil {
+			log.Warnf("license failed to parse file=%s, err=%v", path, err)
+			continue
+		}
+
+		// license header is the comments before package clause
+		for _, cg := range f.Comments {
+			if cg.Pos() > f.Package {
+				break
+			}
+
+			spdx := headerLicense(cg.Text())
+			if spdx == "" || licenseFamily(spdx) == licenseFamily(info.SPDX) {
+				continue
+			}
+
+			filename, _ := filepath.Rel(ctx.Dir, path)
+			collector.add(filename, types.Error{
+				LineNumber:  fset.Position(cg.Pos()).Line,
+				Rule:        "license-header",
+				ErrorString: fmt.Sprintf("license header %s disagrees with root license %s", spdx, info.SPDX),
+			})
+			conflicts++
+			break
+		}
+	}
+
+	score.Summaries = collector.summaries()
+	score.Percentage = 1
+	if len(ctx.Filenames) != 0 {
+		score.Percentage = float64(len(ctx.Filenames)-conflicts) / float64(len(ctx.Filenames))
+	}
+
+	return nil
+}
+
+// detect finds license file in the root dir, and identifies the license of it.
+func (l license) detect(dir string) (*types.LicenseInfo, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "license.ReadDir")
+	}
+
+	info := new(types.LicenseInfo)
+	for _, fi := range fis {
+		if fi.IsDir() || !isLicenseFile(fi.Name()) {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return nil, errors.Wrap(err, "license.ReadFile")
+		}
+
+		spdx, confidence := l.identify(string(data))
+		if info.File == "" || confidence > info.Confidence {
+			info.File = fi.Name()
+			info.SPDX = spdx
+			info.Confidence = confidence
+		}
+	}
+
+	return info, nil
+}
+
+// identify returns the SPDX identifier of text and confidence, template which
+// has most matched shingles wins, so that BSD-3-Clause would not be
+// recognized as BSD-2-Clause.
+func (l license) identify(text string) (spdx string, confidence float64) {
+	if m := _spdxHeader.FindStringSubmatch(text); m != nil {
+		return m[1], 1
+	}
+
+	candidate := shingles(text)
+	var best int
+	for id, tpl := range l.templates {
+		matched := 0
+		for s := range tpl {
+			if _, ok := candidate[s]; ok {
+				matched++
+			}
+		}
+
+		coverage := float64(matched) / float64(len(tpl))
+		if coverage >= licenseMatchThreshold && matched > best {
+			best = matched
+			spdx, confidence = id, coverage
+		}
+	}
+
+	return spdx, confidence
+}
+
+func isLicenseFile(name string) bool {
+	name = strings.ToLower(name)
+	for _, prefix := range _licenseFilePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerLicense returns SPDX identifier of license header, or empty if
+// it's not a license header.
+func headerLicense(text string) string {
+	if m := _spdxHeader.FindStringSubmatch(text); m != nil {
+		return m[1]
+	}
+
+	text = strings.Join(strings.Fields(strings.ToLower(text)), " ")
+	for _, header := range _licenseHeaders {
+		if strings.Contains(text, header.phrase) {
+			return header.spdx
+		}
+	}
+	return ""
+}
+
+// licenseFamily ignores variants of license, such as GPL-3.0-only
+// and GPL-3.0-or-later, BSD-2-Clause and BSD-3-Clause.
+func licenseFamily(spdx string) string {
+	spdx = strings.ToUpper(spdx)
+	if strings.HasPrefix(spdx, "BSD-") {
+		return "BSD"
+	}
+	spdx = strings.TrimSuffix(spdx, "+")
+	spdx = strings.TrimSuffix(spdx, "-ONLY")
+	return strings.TrimSuffix(spdx, "-OR-LATER")
+}
+
+var _nonWord = regexp.MustCompile(`[^a-z0-9]+`)
+
+// shingles returns set of word trigrams of normalized text
+func shingles(text string) map[string]struct{} {
+	words := strings.Fields(_nonWord.ReplaceAllString(strings.ToLower(text), " "))
+	set := make(map[string]struct{}, len(words))
+	for i := 0; i+3 <= len(words); i++ {
+		set[strings.Join(words[i:i+3], " ")] = struct{}{}
+	}
+	return set
+}
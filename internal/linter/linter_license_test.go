@@ -0,0 +1,77 @@
+package linter
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+func Test_license_identify(t *testing.T) {
+	l, err := newLicense(&types.LinterOption{Name: "license", Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bsd2, _ := _licenseCorpus.ReadFile("licenses/BSD-2-Clause.txt")
+	bsd3, _ := _licenseCorpus.ReadFile("licenses/BSD-3-Clause.txt")
+	apache, _ := ioutil.ReadFile("../../LICENSE")
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "case 0", text: "Copyright (c) 2020 yeqown\n\n" + string(bsd2), want: "BSD-2-Clause"},
+		{name: "case 1", text: "Copyright (c) 2020 yeqown\n\n" + string(bsd3), want: "BSD-3-Clause"},
+		{name: "case 2", text: string(apache), want: "Apache-2.0"},
+		{name: "case 3", text: "SPDX-License-Identifier: MIT", want: "MIT"},
+		{name: "case 4", text: "All rights reserved.", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := l.(license).identify(tt.text); got != tt.want {
+				t.Errorf("license.identify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_license_ExecuteDetail(t *testing.T) {
+	dir := t.TempDir()
+	mit, _ := _licenseCorpus.ReadFile("licenses/MIT.txt")
+	files := map[string]string{
+		"LICENSE": string(mit),
+		"a.go":    "// SPDX-License-Identifier: MIT\n\npackage a\n",
+		"b.go":    "// Copyright 2020 The Go Authors. All rights reserved.\n// Use of this source code is governed by a BSD-style\n// license that can be found in the LICENSE file.\n\npackage a\n",
+		"c.go":    "package a\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	filenames, _, err := visitGoFiles(Context{Dir: dir}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := newLicense(&types.LinterOption{Name: "license", Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	score := types.Score{}
+	if err = l.(license).ExecuteDetail(Context{Dir: dir, Filenames: filenames}, &score); err != nil {
+		t.Fatalf("license.ExecuteDetail() error = %v", err)
+	}
+
+	if score.License == nil || score.License.SPDX != "MIT" || score.License.File != "LICENSE" {
+		t.Errorf("license.ExecuteDetail() license = %+v, want MIT in LICENSE", score.License)
+	}
+	if len(score.Summaries) != 1 || score.Summaries[0].Filename != "b.go" {
+		t.Errorf("license.ExecuteDetail() summaries = %+v, want only b.go", score.Summaries)
+	}
+	if want := 2.0 / 3; score.Percentage != want {
+		t.Errorf("license.ExecuteDetail() percentage = %v, want %v", score.Percentage, want)
+	}
+}
@@ -0,0 +1,423 @@
+package linter
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/yeqown/goreportcard/internal/types"
+	"github.com/yeqown/log"
+	"golang.org/x/mod/modfile"
+)
+
+// module is a Go module of repo
+type module struct {
+	path string // module path declared in go.mod
+	dir  string // dir of module relative to root of repo, "." for root
+}
+
+// discoverModules finds all modules of repo in dir. If there is a go.work in
+// dir, only modules used by go.work would be returned, otherwise all go.mod
+// files are found except in hidden and excluded dirs. Modules are sorted by dir.
+func discoverModules(dir string, ex *excluder) ([]module, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "go.work"))
+	switch {
+	case err == nil:
+		return workModules(dir, data)
+	case !os.IsNotExist(err):
+		return nil, errors.Wrap(err, "discoverModules.ReadFile")
+	}
+
+	modules := make([]module, 0, 4)
+	err = filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			// can't walk here, but continue walking elsewhere
+			log.Warnf("discoverModules got err=%v", err)
+			return nil
+		}
+
+		if fi.IsDir() {
+			if p == dir {
+				return nil
+			}
+			rel, _ := filepath.Rel(dir, p)
+			if strings.HasPrefix(fi.Name(), ".") || strings.HasPrefix(fi.Name(), "_") || ex.match(rel) != nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if fi.Name() != "go.mod" {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(dir, filepath.Dir(p))
+		m, err := readModule(dir, rel)
+		if err != nil {
+			log.Warnf("discoverModules skip invalid module=%s, err=%v", rel, err)
+			return nil
+		}
+		modules = append(modules, m)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "discoverModules.Walk")
+	}
+
+	sort.Slice(modules, func(i, j int) bool { return modules[i].dir < modules[j].dir })
+	return modules, nil
+}
+
+// workModules returns modules used by go.work
+func workModules(dir string, data []byte) ([]module, error) {
+	work, err := modfile.ParseWork("go.work", data, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "discoverModules.ParseWork")
+	}
+
+	modules := make([]module, 0, len(work.Use))
+	for _, use := range work.Use {
+		rel := filepath.Clean(filepath.FromSlash(use.Path))
+		if filepath.IsAbs(rel) || strings.HasPrefix(rel, "..") {
+			log.Warnf("discoverModules skip module=%s out of repo", use.Path)
+			continue
+		}
+
+		m, err := readModule(dir, rel)
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, m)
+	}
+
+	sort.Slice(modules, func(i, j int) bool { return modules[i].dir < modules[j].dir })
+	return modules, nil
+}
+
+func readModule(root, rel string) (module, error) {
+	modulePath, err := readModulePath(filepath.Join(root, rel))
+	if err != nil {
+		return module{}, err
+	}
+
+	return module{path: modulePath, dir: filepath.ToSlash(rel)}, nil
+}
+
+// nestedModuleDirs returns dirs of modules which are nested in m,
+// files in them belong to the nested modules rather than m.
+func nestedModuleDirs(root string, m module, modules []module) []string {
+	dirs := make([]string, 0, len(modules))
+	for _, other := range modules {
+		if other.dir == m.dir {
+			continue
+		}
+		if m.dir == "." || strings.HasPrefix(other.dir, m.dir+"/") {
+			dirs = append(dirs, filepath.Join(root, filepath.FromSlash(other.dir)))
+		}
+	}
+
+	return dirs
+}
+
+// excludeDirs removes files in dirs from filenames
+func excludeDirs(filenames []string, dirs []string) []string {
+	if len(dirs) == 0 {
+		return filenames
+	}
+
+	kept := filenames[:0]
+	for _, filename := range filenames {
+		excluded := false
+		for _, dir := range dirs {
+			if strings.HasPrefix(filename, dir+string(filepath.Separator)) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			kept = append(kept, filename)
+		}
+	}
+
+	return kept
+}
+
+// lintModules lints every module in its own dir, and merges results into one,
+// the percentage of each linter is weighted by files count of modules.
+func lintModules(ctx Context, modules []module) (result types.LintResult, err error) {
+	var (
+		scores     = newScoreMerger()
+		testScores = newScoreMerger()
+		results    = make([]types.ModuleResult, 0, len(modules))
+		checks     []string // names of security checks
+	)
+
+	for _, m := range modules {
+		if err = ctx.stdContext().Err(); err != nil {
+			return result, errors.Wrapf(err, "module %s", m.path)
+		}
+
+		mctx := Context{
+			Dir:      filepath.Join(ctx.Dir, filepath.FromSlash(m.dir)),
+			Branch:   ctx.Branch,
+			Ctx:      ctx.Ctx,
+			root:     ctx.Dir,
+			repo:     ctx.repo,
+			Cache:    ctx.Cache,
+			excluder: ctx.excluder,
+			changes:  ctx.changes,
+		}
+		r, err := lintModule(mctx, nestedModuleDirs(ctx.Dir, m, modules))
+		if err == errNoGoFiles {
+			log.Warnf("Lint skip module=%s without .go files", m.path)
+			continue
+		}
+		if err != nil {
+			return result, errors.Wrapf(err, "module %s", m.path)
+		}
+
+		results = append(results, types.ModuleResult{
+			Path:    m.path,
+			Dir:     m.dir,
+			Average: r.Average,
+			Grade:   r.Grade,
+			Files:   r.Files,
+			Issues:  r.Issues,
+		})
+		result.Files += r.Files
+		result.Issues += r.Issues
+		result.Suppressed += r.Suppressed
+		result.TestFiles += r.TestFiles
+		result.TestIssues += r.TestIssues
+		result.TestsGraded = r.TestsGraded
+		result.Excluded = append(result.Excluded, r.Excluded...)
+		result.Generated = append(result.Generated, r.Generated...)
+		result.Size = mergeSize(result.Size, r.Size)
+		result.Cache = mergeCache(result.Cache, r.Cache)
+		result.Dependencies = append(result.Dependencies, r.Dependencies...)
+		result.Vulnerabilities = append(result.Vulnerabilities, r.Vulnerabilities...)
+		result.Base = r.Base
+		result.ChangedFiles += r.ChangedFiles
+		result.ExistingIssues += r.ExistingIssues
+		result.Scoring = r.Scoring
+		if r.Security != nil {
+			checks = r.Security.Checks
+		}
+
+		scores.add(ctx, m, r.Scores, r.Files)
+		testScores.add(ctx, m, r.TestScores, r.TestFiles)
+	}
+	if len(results) == 0 {
+		return result, errNoGoFiles
+	}
+
+	result.Scores = scores.merged()
+	result.TestScores = testScores.merged()
+	sortVulnerabilities(result.Vulnerabilities)
+	result.Security = securityScore(result.Scores, checks)
+	result.Modules = results
+
+	graded := result.Scores
+	if result.TestsGraded {
+		graded = append(append(make([]types.Score, 0, len(result.Scores)+len(result.TestScores)),
+			result.Scores...), result.TestScores...)
+	}
+	result.Average = weightedAverage(graded)
+	result.Grade = types.GradeFromPercentage(result.Average * 100)
+	return result, nil
+}
+
+// scoreMerger merges scores of the same linter in modules
+type scoreMerger struct {
+	scores map[string]*types.Score // map[linterName]score
+	files  map[string]int          // map[linterName]filesCount
+	names  []string                // names of linters in order
+}
+
+func newScoreMerger() *scoreMerger {
+	return &scoreMerger{
+		scores: make(map[string]*types.Score, 16),
+		files:  make(map[string]int, 16),
+		names:  make([]string, 0, 16),
+	}
+}
+
+// add scores of module m which has files, percentages are weighted by files.
+func (sm *scoreMerger) add(ctx Context, m module, scores []types.Score, files int) {
+	for _, score := range scores {
+		merged, ok := sm.scores[score.Name]
+		if !ok {
+			merged = &types.Score{Name: score.Name, Desc: score.Desc, Weight: score.Weight}
+			sm.scores[score.Name] = merged
+			sm.names = append(sm.names, score.Name)
+		}
+		mergeScore(ctx, m, merged, score)
+		if score.State == types.ScoreTimeout {
+			continue
+		}
+		merged.Percentage += score.Percentage * float64(files)
+		sm.files[score.Name] += files
+	}
+}
+
+// merged returns merged scores sorted by weight.
+func (sm *scoreMerger) merged() types.ByWeight {
+	if len(sm.names) == 0 {
+		return nil
+	}
+
+	merged := make(types.ByWeight, 0, len(sm.names))
+	for _, name := range sm.names {
+		score := sm.scores[name]
+		if sm.files[name] == 0 {
+			// timeout in all modules
+			score.State = types.ScoreTimeout
+		} else {
+			score.Percentage /= float64(sm.files[name])
+		}
+		merged = append(merged, *score)
+	}
+	sort.Stable(merged)
+
+	return merged
+}
+
+// mergeScore merges score of module m into merged, filenames are relative
+// to the root of repo after merged.
+func mergeScore(ctx Context, m module, merged *types.Score, score types.Score) {
+	for _, summary := range score.Summaries {
+		summary.Filename = path.Join(m.dir, summary.Filename)
+		summary.FileURL = assembleRemoteFileURI(ctx.Dir, ctx.Branch, summary.Filename)
+		merged.Summaries = append(merged.Summaries, summary)
+	}
+
+	// failed in any module is failed, and timeout only if all modules timeout
+	switch {
+	case score.State == types.ScoreFailed:
+		merged.State = types.ScoreFailed
+	case score.State == types.ScoreOK && merged.State != types.ScoreFailed:
+		merged.State = types.ScoreOK
+	}
+	for _, issue := range score.Suppressed {
+		issue.Filename = path.Join(m.dir, issue.Filename)
+		issue.FileURL = assembleRemoteFileURI(ctx.Dir, ctx.Branch, issue.Filename)
+		merged.Suppressed = append(merged.Suppressed, issue)
+	}
+	if score.Error != "" {
+		if merged.Error != "" {
+			merged.Error += "; "
+		}
+		merged.Error += m.path + ": " + score.Error
+	}
+	merged.Existing += score.Existing
+	if merged.License == nil {
+		merged.License = score.License
+	}
+	merged.Complexity = mergeComplexity(merged.Complexity, score.Complexity)
+	merged.Docs = mergeDocs(m, merged.Docs, score.Docs)
+	merged.Duplicates = mergeDuplicates(ctx, m, merged.Duplicates, score.Duplicates)
+}
+
+// mergeDocs merges documentation coverage of module m into merged, dirs of
+// packages are relative to the root of repo after merged.
+func mergeDocs(m module, merged, docs *types.DocStats) *types.DocStats {
+	if docs == nil {
+		return merged
+	}
+	if merged == nil {
+		merged = &types.DocStats{}
+	}
+
+	merged.Add(docs.DocCounts)
+	for _, pkg := range docs.Packages {
+		pkg.Dir = path.Join(m.dir, pkg.Dir)
+		merged.Packages = append(merged.Packages, pkg)
+	}
+	return merged
+}
+
+// mergeDuplicates merges duplicated code of module m into merged, filenames
+// are relative to the root of repo after merged. Clones across modules are
+// not detected.
+func mergeDuplicates(ctx Context, m module, merged, dups *types.DupStats) *types.DupStats {
+	if dups == nil {
+		return merged
+	}
+	if merged == nil {
+		merged = &types.DupStats{}
+	}
+
+	merged.Lines += dups.Lines
+	merged.DuplicatedLines += dups.DuplicatedLines
+	for _, group := range dups.Groups {
+		locations := make([]types.CloneLocation, 0, len(group.Locations))
+		for _, loc := range group.Locations {
+			loc.Filename = path.Join(m.dir, loc.Filename)
+			loc.FileURL = assembleRemoteFileURI(ctx.Dir, ctx.Branch, loc.Filename)
+			locations = append(locations, loc)
+		}
+		group.Locations = locations
+		merged.Groups = append(merged.Groups, group)
+	}
+	sortCloneGroups(merged.Groups)
+	return merged
+}
+
+// mergeComplexity merges complexity stats of modules, P90 of merged
+// is the max P90 of modules, since values are not kept.
+func mergeComplexity(a, b *types.ComplexityStats) *types.ComplexityStats {
+	if a == nil || b == nil {
+		if a == nil {
+			return b
+		}
+		return a
+	}
+
+	functions := a.Functions + b.Functions
+	if functions == 0 {
+		return a
+	}
+
+	merge := func(x, y types.Distribution) types.Distribution {
+		d := types.Distribution{
+			Average: (x.Average*float64(a.Functions) + y.Average*float64(b.Functions)) / float64(functions),
+			P90:     x.P90,
+			Max:     x.Max,
+		}
+		if y.P90 > d.P90 {
+			d.P90 = y.P90
+		}
+		if y.Max > d.Max {
+			d.Max = y.Max
+		}
+		return d
+	}
+
+	return &types.ComplexityStats{
+		Functions:  functions,
+		Cyclomatic: merge(a.Cyclomatic, b.Cyclomatic),
+		Cognitive:  merge(a.Cognitive, b.Cognitive),
+	}
+}
+
+// mergeSize merges code size of modules, paths of both are relative to root
+// of repo already.
+func mergeSize(dst, src *types.SizeStats) *types.SizeStats {
+	if dst == nil {
+		return src
+	}
+	if src == nil {
+		return dst
+	}
+
+	dst.CodeStats.Add(src.CodeStats)
+	dst.Packages = append(dst.Packages, src.Packages...)
+	dst.Files = append(dst.Files, src.Files...)
+	sort.Slice(dst.Packages, func(i, j int) bool {
+		return dst.Packages[i].Dir < dst.Packages[j].Dir
+	})
+	return dst
+}
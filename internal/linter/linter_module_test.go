@@ -0,0 +1,94 @@
+package linter
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+func Test_discoverModules(t *testing.T) {
+	tests := []struct {
+		name string
+		dir  string
+		want []module
+	}{
+		{
+			name: "case 0",
+			dir:  "testdata/analysis",
+			want: []module{{path: "example.com/analysis", dir: "."}},
+		},
+		{
+			name: "case 1",
+			dir:  "testdata/modules",
+			want: []module{
+				{path: "example.com/root", dir: "."},
+				{path: "example.com/root/sub", dir: "sub"},
+			},
+		},
+		{
+			name: "case 2",
+			dir:  "testdata/work",
+			want: []module{
+				{path: "example.com/a", dir: "a"},
+				{path: "example.com/b", dir: "b"},
+			},
+		},
+	}
+	ex, err := newExcluder(nil, nil, nil)
+	if err != nil {
+		t.Fatalf("newExcluder() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := discoverModules(tt.dir, ex)
+			if err != nil {
+				t.Fatalf("discoverModules() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("discoverModules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_excludeDirs(t *testing.T) {
+	root := "testdata/modules"
+	modules := []module{{path: "example.com/root", dir: "."}, {path: "example.com/root/sub", dir: "sub"}}
+
+	filenames, _, err := visitGoFiles(Context{Dir: root}, root)
+	if err != nil {
+		t.Fatalf("visitGoFiles() error = %v", err)
+	}
+	got := excludeDirs(filenames, nestedModuleDirs(root, modules[0], modules))
+	want := []string{filepath.Join(root, "root.go")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("excludeDirs() = %v, want %v", got, want)
+	}
+}
+
+func Test_mergeComplexity(t *testing.T) {
+	a := &types.ComplexityStats{
+		Functions:  1,
+		Cyclomatic: types.Distribution{Average: 2, P90: 2, Max: 2},
+		Cognitive:  types.Distribution{Average: 1, P90: 1, Max: 1},
+	}
+	b := &types.ComplexityStats{
+		Functions:  3,
+		Cyclomatic: types.Distribution{Average: 6, P90: 8, Max: 10},
+		Cognitive:  types.Distribution{Average: 1, P90: 1, Max: 1},
+	}
+	want := &types.ComplexityStats{
+		Functions:  4,
+		Cyclomatic: types.Distribution{Average: 5, P90: 8, Max: 10},
+		Cognitive:  types.Distribution{Average: 1, P90: 1, Max: 1},
+	}
+
+	if got := mergeComplexity(nil, a); got != a {
+		t.Errorf("mergeComplexity(nil, a) = %v, want %v", got, a)
+	}
+	if got := mergeComplexity(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeComplexity(a, b) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,149 @@
+package linter
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/yeqown/goreportcard/internal/types"
+	"github.com/yeqown/log"
+)
+
+var _ ILinter = plugin{}
+
+// plugin is a linter provided by an external executable, it reads pluginInput
+// as JSON from stdin, and writes []types.FileSummary as JSON into stdout, for
+// example:
+//
+//	stdin:  {"dir": "/repos/github.com/a/b", "files": ["main.go", "pkg/x.go"], "tests": false}
+//	stdout: [{"filename": "pkg/x.go", "errors": [{"line_number": 3, "error_string": "banned import"}]}]
+//
+// Filenames are relative to dir. A non-zero exit status means the plugin failed,
+// and it would be killed when the timeout of linter is reached.
+type plugin struct {
+	name    string   // linter's name
+	desc    string   // linter's desc
+	weight  float64  // linter's weight
+	command []string // executable and its arguments
+}
+
+// pluginInput is written into stdin of plugin
+type pluginInput struct {
+	Dir   string   `json:"dir"`   // absolute dir of repo or module
+	Files []string `json:"files"` // .go files to lint, relative to Dir
+	Tests bool     `json:"tests"` // Files are test files or not
+}
+
+func newPlugin(opt *types.LinterOption) (ILinter, error) {
+	if len(opt.Command) == 0 {
+		return nil, errors.Errorf("plugin %q: command is required", opt.Name)
+	}
+
+	return plugin{
+		name:    opt.Name,
+		desc:    opt.Desc,
+		weight:  opt.Weight,
+		command: opt.Command,
+	}, nil
+}
+
+func (p plugin) Name() string {
+	return p.name
+}
+
+func (p plugin) Description() string {
+	return p.desc
+}
+
+func (p plugin) Weight() float64 {
+	return p.weight
+}
+
+func (p plugin) Execute(ctx Context) (float64, []types.FileSummary, error) {
+	dir, err := filepath.Abs(ctx.Dir)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "plugin.Abs")
+	}
+
+	input := pluginInput{
+		Dir:   dir,
+		Files: make([]string, 0, len(ctx.Filenames)),
+		Tests: ctx.tests,
+	}
+	files := make(map[string]struct{}, len(ctx.Filenames))
+	for _, filename := range ctx.Filenames {
+		rel, err := filepath.Rel(ctx.Dir, filename)
+		if err != nil {
+			return 0, nil, errors.Wrap(err, "plugin.Rel")
+		}
+		rel = filepath.ToSlash(rel)
+		input.Files = append(input.Files, rel)
+		files[rel] = struct{}{}
+	}
+
+	out, err := p.run(ctx, input)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var output []types.FileSummary
+	if err = json.Unmarshal(out, &output); err != nil {
+		return 0, nil, errors.Wrapf(err, "plugin %s: invalid output", p.name)
+	}
+
+	collector := newSummaryCollector(ctx)
+	for _, summary := range output {
+		filename := filepath.ToSlash(summary.Filename)
+		if filepath.IsAbs(summary.Filename) {
+			if filename, err = filepath.Rel(dir, summary.Filename); err != nil {
+				continue
+			}
+			filename = filepath.ToSlash(filename)
+		}
+		if _, ok := files[filename]; !ok {
+			log.Warnf("plugin %s reported file=%s which is not linted, ignored", p.name, summary.Filename)
+			continue
+		}
+
+		for _, e := range summary.Errors {
+			if e.Rule == "" {
+				e.Rule = p.name
+			}
+			collector.add(filename, e)
+		}
+	}
+
+	summaries := collector.summaries()
+	percentage, err := calcPercentage(ctx, summaries)
+	return percentage, summaries, err
+}
+
+// run executes the plugin in ctx.Dir with input in stdin, and returns stdout.
+func (p plugin) run(ctx Context, input pluginInput) ([]byte, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, errors.Wrap(err, "plugin.jsonMarshal")
+	}
+
+	cmd := exec.CommandContext(ctx.stdContext(), p.command[0], p.command[1:]...)
+	cmd.Dir = input.Dir
+	cmd.Stdin = bytes.NewReader(data)
+	killProcessGroup(cmd)
+	cmd.WaitDelay = time.Second
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		if ctxErr := ctx.stdContext().Err(); ctxErr != nil {
+			return nil, errors.Wrapf(ctxErr, "plugin %s", p.name)
+		}
+		return nil, errors.Wrapf(err, "plugin %s: %s", p.name, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,62 @@
+package linter
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+func Test_plugin_Execute(t *testing.T) {
+	script, _ := filepath.Abs("testdata/plugin/plugin.sh")
+	dir := "testdata/plugin"
+	ctx := Context{
+		Dir:       dir,
+		Filenames: []string{filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")},
+		Branch:    types.MasterBranch,
+	}
+
+	p, err := newPlugin(&types.LinterOption{Name: "banned", Command: []string{script}})
+	if err != nil {
+		t.Fatalf("newPlugin() error = %v", err)
+	}
+	percentage, summaries, err := p.Execute(ctx)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	want := []types.FileSummary{{
+		Filename: "a.go",
+		FileURL:  assembleRemoteFileURI(dir, types.MasterBranch, "a.go"),
+		Errors: []types.Error{{
+			LineNumber: 1, Rule: "banned", ErrorString: "banned",
+			SourceLines: []string{"package plugin"},
+		}},
+	}}
+	if !reflect.DeepEqual(summaries, want) {
+		t.Errorf("Execute() summaries = %+v, want %+v", summaries, want)
+	}
+	if percentage != .5 {
+		t.Errorf("Execute() percentage = %v, want 0.5", percentage)
+	}
+
+	failed, _ := newPlugin(&types.LinterOption{Name: "banned", Command: []string{script, "fail"}})
+	if _, _, err = failed.Execute(ctx); err == nil {
+		t.Errorf("Execute() with failed plugin, want error")
+	}
+
+	sleep, _ := newPlugin(&types.LinterOption{Name: "banned", Command: []string{script, "sleep"}})
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	ctx.Ctx = timeoutCtx
+	start := time.Now()
+	if _, _, err = sleep.Execute(ctx); err == nil {
+		t.Errorf("Execute() with timeout, want error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Execute() with timeout took %s, want killed", elapsed)
+	}
+}
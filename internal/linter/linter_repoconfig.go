@@ -0,0 +1,186 @@
+package linter
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/pkg/errors"
+	"github.com/yeqown/goreportcard/internal/types"
+	"github.com/yeqown/log"
+	"gopkg.in/yaml.v3"
+)
+
+// _repoConfigFile is the optional config file in the root of repo
+const _repoConfigFile = ".goreportcard.yml"
+
+// repoConfig is `.goreportcard.yml` in the root of repo, for example:
+//
+//	exclude:
+//	  - "gen/**"
+//	suppress:
+//	  - linter: errcheck
+//	    path: "internal/legacy/**"
+//	    reason: "legacy code would be removed soon"
+//	weights:
+//	  lll: 0.05
+//
+// What it could do is limited by types.RepoConfigOption of instance.
+type repoConfig struct {
+	Exclude  []string           `yaml:"exclude"`  // globs of paths relative to root of repo
+	Suppress []suppression      `yaml:"suppress"` // suppressions of issues
+	Weights  map[string]float64 `yaml:"weights"`  // map[linterName]weight
+}
+
+// suppression suppresses issues which match all of its non-empty
+// Linter, Rule and Path, and Reason is required.
+type suppression struct {
+	Linter string `yaml:"linter"` // name of linter
+	Rule   string `yaml:"rule"`   // types.Error.Rule
+	Path   string `yaml:"path"`   // glob of path relative to root of repo
+	Reason string `yaml:"reason"`
+}
+
+// loadRepoConfig reads `.goreportcard.yml` in root, nil would be returned if
+// it does not exist. Parts which are not allowed by opt are dropped, and
+// weights are limited in the allowed range.
+func loadRepoConfig(root string, opt types.RepoConfigOption) (*repoConfig, error) {
+	data, err := ioutil.ReadFile(filepath.Join(root, _repoConfigFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "loadRepoConfig.ReadFile")
+	}
+
+	rc := new(repoConfig)
+	if err = yaml.Unmarshal(data, rc); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s", _repoConfigFile)
+	}
+	if err = rc.validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s", _repoConfigFile)
+	}
+
+	if !opt.AllowExcludes && len(rc.Exclude) != 0 {
+		log.Warnf("loadRepoConfig: excludes are not allowed, ignored")
+		rc.Exclude = nil
+	}
+	if !opt.AllowSuppressions && len(rc.Suppress) != 0 {
+		log.Warnf("loadRepoConfig: suppressions are not allowed, ignored")
+		rc.Suppress = nil
+	}
+	if !opt.AllowWeights() && len(rc.Weights) != 0 {
+		log.Warnf("loadRepoConfig: weight overrides are not allowed, ignored")
+		rc.Weights = nil
+	}
+	for name, weight := range rc.Weights {
+		rc.Weights[name] = math.Min(math.Max(weight, opt.MinWeight), opt.MaxWeight)
+	}
+
+	return rc, nil
+}
+
+func (rc *repoConfig) validate() error {
+	for idx, pattern := range rc.Exclude {
+		if !doublestar.ValidatePattern(pattern) {
+			return errors.Errorf("exclude[%d]: invalid glob %q", idx, pattern)
+		}
+	}
+
+	for idx, s := range rc.Suppress {
+		if s.Reason == "" {
+			return errors.Errorf("suppress[%d]: reason is required", idx)
+		}
+		if s.Linter == "" && s.Rule == "" && s.Path == "" {
+			return errors.Errorf("suppress[%d]: at least one of linter, rule and path is required", idx)
+		}
+		if s.Path != "" && !doublestar.ValidatePattern(s.Path) {
+			return errors.Errorf("suppress[%d]: invalid glob %q", idx, s.Path)
+		}
+	}
+
+	for name, weight := range rc.Weights {
+		if !(weight > 0) || math.IsInf(weight, 0) {
+			return errors.Errorf("weights: weight of %q is invalid (%v)", name, weight)
+		}
+	}
+
+	return nil
+}
+
+// suppressed returns the suppression which matches the issue, path is
+// relative to root of repo.
+func (rc *repoConfig) suppressed(linter, path string, err types.Error) *suppression {
+	if rc == nil {
+		return nil
+	}
+
+	path = filepath.ToSlash(path)
+	for idx, s := range rc.Suppress {
+		if s.Linter != "" && s.Linter != linter {
+			continue
+		}
+		if s.Rule != "" && s.Rule != err.Rule {
+			continue
+		}
+		if ok, _ := doublestar.Match(s.Path, path); s.Path != "" && !ok {
+			continue
+		}
+		return &rc.Suppress[idx]
+	}
+	return nil
+}
+
+// weight returns the overridden weight of linter, or def if not overridden.
+func (rc *repoConfig) weight(linter string, def float64) float64 {
+	if rc == nil {
+		return def
+	}
+
+	if w, ok := rc.Weights[linter]; ok {
+		return w
+	}
+	return def
+}
+
+// filter moves suppressed issues from summaries of score into score.Suppressed,
+// excluded paths are filtered by excluder. It reports whether summaries are changed.
+func (rc *repoConfig) filter(ctx Context, score *types.Score) bool {
+	if rc == nil || len(rc.Suppress) == 0 {
+		return false
+	}
+
+	changed := false
+	kept := score.Summaries[:0]
+	for _, summary := range score.Summaries {
+		path := ctx.rootRelative(summary.Filename)
+
+		errs := summary.Errors[:0]
+		for _, err := range summary.Errors {
+			s := rc.suppressed(score.Name, path, err)
+			if s == nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			score.Suppressed = append(score.Suppressed, types.SuppressedIssue{
+				Filename: summary.Filename,
+				FileURL:  summary.FileURL,
+				Error:    err,
+				Reason:   s.Reason,
+			})
+			changed = true
+		}
+		if len(errs) == 0 {
+			continue
+		}
+
+		summary.Errors = errs
+		kept = append(kept, summary)
+	}
+	score.Summaries = kept
+
+	return changed
+}
@@ -0,0 +1,128 @@
+package linter
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+func Test_loadRepoConfig(t *testing.T) {
+	allowAll := types.RepoConfigOption{
+		AllowExcludes:     true,
+		AllowSuppressions: true,
+		MinWeight:         .05,
+		MaxWeight:         .5,
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		opt     types.RepoConfigOption
+		want    *repoConfig
+		wantErr bool
+	}{
+		{
+			name: "case 0",
+			content: `
+exclude: ["gen/**"]
+suppress:
+  - linter: errcheck
+    path: "legacy/**"
+    reason: frozen
+weights:
+  lll: 0.01
+  govet: 0.3
+`,
+			opt: allowAll,
+			want: &repoConfig{
+				Exclude:  []string{"gen/**"},
+				Suppress: []suppression{{Linter: "errcheck", Path: "legacy/**", Reason: "frozen"}},
+				Weights:  map[string]float64{"lll": .05, "govet": .3},
+			},
+		},
+		{
+			name: "case 1",
+			content: `
+suppress:
+  - linter: errcheck
+`,
+			opt:     allowAll,
+			wantErr: true,
+		},
+		{
+			name: "case 2",
+			content: `
+suppress:
+  - reason: no matcher
+`,
+			opt:     allowAll,
+			wantErr: true,
+		},
+		{
+			name: "case 3",
+			content: `
+exclude: ["gen/**"]
+suppress:
+  - rule: cyclomatic
+    reason: generated parser
+weights:
+  lll: 0.1
+`,
+			opt:  types.RepoConfigOption{},
+			want: &repoConfig{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := ioutil.WriteFile(filepath.Join(dir, _repoConfigFile), []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := loadRepoConfig(dir, tt.opt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadRepoConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadRepoConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repoConfig_filter(t *testing.T) {
+	rc := &repoConfig{
+		Suppress: []suppression{
+			{Linter: "errcheck", Path: "legacy/*.go", Reason: "frozen"},
+			{Rule: "cyclomatic", Reason: "parser"},
+		},
+	}
+	score := types.Score{
+		Name: "errcheck",
+		Summaries: []types.FileSummary{
+			{Filename: "legacy/b.go", Errors: []types.Error{{LineNumber: 2}}},
+			{Filename: "c.go", Errors: []types.Error{{LineNumber: 3}, {LineNumber: 4, Rule: "cyclomatic"}}},
+		},
+	}
+
+	if changed := rc.filter(Context{Dir: "."}, &score); !changed {
+		t.Errorf("filter() = false, want true")
+	}
+
+	wantSummaries := []types.FileSummary{
+		{Filename: "c.go", Errors: []types.Error{{LineNumber: 3}}},
+	}
+	if !reflect.DeepEqual(score.Summaries, wantSummaries) {
+		t.Errorf("filter() summaries = %+v, want %+v", score.Summaries, wantSummaries)
+	}
+	wantSuppressed := []types.SuppressedIssue{
+		{Filename: "legacy/b.go", Error: types.Error{LineNumber: 2}, Reason: "frozen"},
+		{Filename: "c.go", Error: types.Error{LineNumber: 4, Rule: "cyclomatic"}, Reason: "parser"},
+	}
+	if !reflect.DeepEqual(score.Suppressed, wantSuppressed) {
+		t.Errorf("filter() suppressed = %+v, want %+v", score.Suppressed, wantSuppressed)
+	}
+}
@@ -0,0 +1,207 @@
+package linter
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+// IScorer calculates the percentage of a linter from its issues,
+// scorer of Lint is chosen by `scoring.strategy` of config.
+type IScorer interface {
+	// Name of scoring strategy
+	Name() string
+
+	// Percentage returns the percentage in [0, 1] of the linter's summaries
+	Percentage(ctx Context, summaries []types.FileSummary) (float64, error)
+}
+
+const (
+	// defaultScoringLimit is the issues per 1000 lines which scores 0
+	defaultScoringLimit = 10
+)
+
+// _defaultSeverities are weights of severities for severity-weighted scoring,
+// unknown or empty severity weights 1.
+var _defaultSeverities = map[string]float64{
+	"error":   1,
+	"warning": .5,
+	"info":    .2,
+}
+
+// _linterSeverities are default severities of issues of golangci-lint and
+// analysis linters, golangci-lint sets severity only if `severity` rules are
+// configured. Issues of other linters have no severity and weight 1.
+var _linterSeverities = map[string]string{
+	// bugs and code which could not compile
+	"typecheck":   "error",
+	"govet":       "error",
+	"vet":         "error",
+	"errcheck":    "error",
+	"staticcheck": "error",
+	"nilness":     "error",
+	"bodyclose":   "error",
+	// unused code and simplifications
+	"ineffassign": "warning",
+	"deadcode":    "warning",
+	"unused":      "warning",
+	"varcheck":    "warning",
+	"structcheck": "warning",
+	"unusedwrite": "warning",
+	"shadow":      "warning",
+	"gosimple":    "warning",
+	"unparam":     "warning",
+	// style
+	"lll":        "info",
+	"funlen":     "info",
+	"nestif":     "info",
+	"gocyclo":    "info",
+	"gocognit":   "info",
+	"golint":     "info",
+	"stylecheck": "info",
+	"misspell":   "info",
+	"whitespace": "info",
+	"goimports":  "info",
+	"gofmt":      "info",
+}
+
+// defaultSeverity returns severity if it's set, or the default severity of
+// the linter.
+func defaultSeverity(linterName, severity string) string {
+	if severity != "" {
+		return severity
+	}
+	return _linterSeverities[linterName]
+}
+
+// newScorer creates scorer by config option.
+func newScorer(opt types.ScoringOption) IScorer {
+	limit := opt.Limit
+	if limit == 0 {
+		limit = defaultScoringLimit
+	}
+
+	switch opt.Strategy {
+	case types.IssuesPerKLOCScoring:
+		return &density{
+			name:  string(opt.Strategy),
+			limit: limit,
+		}
+	case types.SeverityWeightedScoring:
+		severities := make(map[string]float64, len(_defaultSeverities)+len(opt.Severities))
+		for severity, weight := range _defaultSeverities {
+			severities[severity] = weight
+		}
+		for severity, weight := range opt.Severities {
+			severities[strings.ToLower(severity)] = weight
+		}
+		return &density{
+			name:       string(opt.Strategy),
+			limit:      limit,
+			severities: severities,
+		}
+	}
+
+	return fileRatio{}
+}
+
+var _ IScorer = fileRatio{}
+
+// fileRatio is the share of files without any issue, one issue and fifty
+// issues in a file are the same.
+type fileRatio struct{}
+
+func (fileRatio) Name() string {
+	return string(types.FileRatioScoring)
+}
+
+func (fileRatio) Percentage(ctx Context, summaries []types.FileSummary) (float64, error) {
+	// TRUE: sif only 1 file, so calc score = sum(error line) / sum(line)
+	if len(ctx.Filenames) == 1 {
+		lc, err := lineCount(ctx.Filenames[0])
+		if err != nil {
+			return 0, err
+		}
+
+		errCnt := 0
+		if len(summaries) != 0 {
+			errCnt = len(summaries[0].Errors)
+		}
+
+		return float64(lc-errCnt) / float64(lc), nil
+	}
+
+	// ELSE: sum(no error file) / sum(file)
+	return float64(len(ctx.Filenames)-len(summaries)) / float64(len(ctx.Filenames)), nil
+}
+
+var _ IScorer = &density{}
+
+// density scores by issues per 1000 lines of code: 1 - density / limit,
+// and 0 if density is over limit. If severities is set, issues are
+// weighted by their severity.
+type density struct {
+	name       string
+	limit      float64            // issues per 1000 lines which scores 0
+	severities map[string]float64 // weights of severities, nil means all issues weight 1
+
+	// lines of all files are counted only once, and shared by all linters
+	once  sync.Once
+	lines int
+	err   error
+}
+
+func (d *density) Name() string {
+	return d.name
+}
+
+func (d *density) Percentage(ctx Context, summaries []types.FileSummary) (float64, error) {
+	d.once.Do(func() {
+		d.lines, d.err = totalLines(ctx.Filenames)
+	})
+	if d.err != nil {
+		return 0, d.err
+	}
+	if d.lines == 0 {
+		return 1, nil
+	}
+
+	var issues float64
+	for _, summary := range summaries {
+		for _, err := range summary.Errors {
+			issues += d.weight(err.Severity)
+		}
+	}
+
+	kloc := float64(d.lines) / 1000
+	p := 1 - issues/kloc/d.limit
+	if p < 0 {
+		p = 0
+	}
+	return p, nil
+}
+
+func (d *density) weight(severity string) float64 {
+	if d.severities == nil {
+		return 1
+	}
+	if w, ok := d.severities[strings.ToLower(severity)]; ok {
+		return w
+	}
+	return 1
+}
+
+// totalLines counts lines of all files
+func totalLines(filenames []string) (int, error) {
+	total := 0
+	for _, filename := range filenames {
+		lc, err := lineCount(filename)
+		if err != nil {
+			return 0, err
+		}
+		total += lc
+	}
+
+	return total, nil
+}
@@ -0,0 +1,113 @@
+package linter
+
+import (
+	"math"
+	"testing"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+func Test_scorer(t *testing.T) {
+	// a.go and b.go have 17 lines totally
+	filenames := []string{"testdata/analysis/a.go", "testdata/analysis/b.go"}
+	summaries := []types.FileSummary{
+		{
+			Filename: "a.go",
+			Errors: []types.Error{
+				{LineNumber: 1, Severity: "warning"},
+				{LineNumber: 2, Severity: "Error"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		opt      types.ScoringOption
+		wantName string
+		want     float64
+	}{
+		{
+			name:     "case 0",
+			opt:      types.ScoringOption{},
+			wantName: "file-ratio",
+			want:     .5,
+		},
+		{
+			name:     "case 1",
+			opt:      types.ScoringOption{Strategy: types.IssuesPerKLOCScoring, Limit: 200},
+			wantName: "issues-per-kloc",
+			want:     1 - 2/(17./1000)/200,
+		},
+		{
+			name:     "case 2",
+			opt:      types.ScoringOption{Strategy: types.IssuesPerKLOCScoring},
+			wantName: "issues-per-kloc",
+			want:     0,
+		},
+		{
+			name: "case 3",
+			opt: types.ScoringOption{
+				Strategy:   types.SeverityWeightedScoring,
+				Limit:      200,
+				Severities: map[string]float64{"warning": .1},
+			},
+			wantName: "severity-weighted",
+			want:     1 - 1.1/(17./1000)/200,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scorer := newScorer(tt.opt)
+			if scorer.Name() != tt.wantName {
+				t.Errorf("Name() = %v, want %v", scorer.Name(), tt.wantName)
+			}
+
+			got, err := scorer.Percentage(Context{Filenames: filenames}, summaries)
+			if err != nil {
+				t.Fatalf("Percentage() error = %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Percentage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_scorer_defaultSeverities(t *testing.T) {
+	// without severity rules of golangci-lint, severities are the defaults of linters
+	data := []byte(`{"Issues":[
+{"FromLinter":"errcheck","Text":"Error return value is not checked","Pos":{"Filename":"a.go","Line":3,"Column":2}},
+{"FromLinter":"lll","Text":"line is 130 characters","Pos":{"Filename":"a.go","Line":1,"Column":1}},
+{"FromLinter":"lll","Text":"line is 140 characters","Pos":{"Filename":"a.go","Line":2,"Column":1}},
+{"FromLinter":"lll","Text":"line is 150 characters","Pos":{"Filename":"b.go","Line":3,"Column":1}}
+]}`)
+	ctx := Context{
+		Dir:       "testdata/analysis",
+		Branch:    types.MasterBranch,
+		Filenames: []string{"testdata/analysis/a.go", "testdata/analysis/b.go"},
+	}
+	issues, err := parseGolangciLintInJSON(ctx, data)
+	if err != nil {
+		t.Fatalf("parseGolangciLintInJSON() error = %v", err)
+	}
+
+	percentages := func(strategy types.ScoringStrategy) (errcheck, lll float64) {
+		scorer := newScorer(types.ScoringOption{Strategy: strategy, Limit: 200})
+		if errcheck, err = scorer.Percentage(ctx, issues["errcheck"]); err != nil {
+			t.Fatalf("Percentage() error = %v", err)
+		}
+		if lll, err = scorer.Percentage(ctx, issues["lll"]); err != nil {
+			t.Fatalf("Percentage() error = %v", err)
+		}
+		return errcheck, lll
+	}
+
+	// one error is better than three style issues by count
+	if errcheck, lll := percentages(types.IssuesPerKLOCScoring); errcheck <= lll {
+		t.Errorf("issues-per-kloc: errcheck = %v, lll = %v, want errcheck > lll", errcheck, lll)
+	}
+	// but worse by severity, 1 > 3 * 0.2
+	if errcheck, lll := percentages(types.SeverityWeightedScoring); errcheck >= lll {
+		t.Errorf("severity-weighted: errcheck = %v, lll = %v, want errcheck < lll", errcheck, lll)
+	}
+}
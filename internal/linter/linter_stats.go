@@ -0,0 +1,199 @@
+package linter
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/yeqown/goreportcard/internal/types"
+	"github.com/yeqown/log"
+)
+
+// codeSize counts code size of files which are walked from ctx.Dir, packages
+// are grouped by dir of files. Paths in result are relative to root of repo.
+func codeSize(ctx Context, filenames []string) (*types.SizeStats, error) {
+	size := &types.SizeStats{
+		Files: make([]types.FileStats, 0, len(filenames)),
+	}
+
+	root := ctx.root
+	if root == "" {
+		root = ctx.Dir
+	}
+
+	packages := make(map[string]*types.PackageStats, 8) // map[dir]stats
+	for _, filename := range filenames {
+		stats, err := fileStats(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		rel, err := filepath.Rel(root, filename)
+		if err != nil {
+			return nil, errors.Wrap(err, "codeSize.Rel")
+		}
+		rel = filepath.ToSlash(rel)
+		size.Files = append(size.Files, types.FileStats{Filename: rel, CodeStats: stats})
+		size.CodeStats.Add(stats)
+
+		dir := path.Dir(rel)
+		pkg, ok := packages[dir]
+		if !ok {
+			pkg = &types.PackageStats{Dir: dir}
+			packages[dir] = pkg
+		}
+		pkg.Files++
+		pkg.CodeStats.Add(stats)
+	}
+
+	size.Packages = make([]types.PackageStats, 0, len(packages))
+	for _, pkg := range packages {
+		size.Packages = append(size.Packages, *pkg)
+	}
+	sort.Slice(size.Packages, func(i, j int) bool {
+		return size.Packages[i].Dir < size.Packages[j].Dir
+	})
+
+	return size, nil
+}
+
+// lineCount returns the number of lines in a given file
+func lineCount(filename string) (int, error) {
+	stats, err := fileStats(filename)
+	if err != nil {
+		return 0, err
+	}
+	return stats.Lines, nil
+}
+
+// fileStats counts lines and exported symbols of a Go file. A line is a code
+// line if any token other than comments is on it, a comment line if only
+// comments are on it, or a blank line.
+func fileStats(filename string) (types.CodeStats, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return types.CodeStats{}, errors.Wrap(err, "fileStats.ReadFile")
+	}
+
+	var (
+		stats types.CodeStats
+		fset  = token.NewFileSet()
+		file  = fset.AddFile(filename, -1, len(src))
+		s     scanner.Scanner
+	)
+	// errors are ignored, counting lines should not fail on invalid code
+	s.Init(file, src, nil, scanner.ScanComments)
+
+	code := make(map[int]struct{}, 64)
+	comment := make(map[int]struct{}, 64)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			// automatically inserted
+			continue
+		}
+
+		marks := code
+		if tok == token.COMMENT {
+			marks = comment
+		}
+		start := file.Line(pos)
+		end := file.Line(pos + token.Pos(len(lit)-1))
+		if lit == "" {
+			end = start
+		}
+		for line := start; line <= end; line++ {
+			marks[line] = struct{}{}
+		}
+	}
+
+	if len(src) != 0 {
+		stats.Lines = file.LineCount()
+	}
+	stats.Code = len(code)
+	for line := range comment {
+		if _, ok := code[line]; !ok {
+			stats.Comments++
+		}
+	}
+	stats.Blanks = stats.Lines - stats.Code - stats.Comments
+
+	stats.Exported, err = exportedSymbols(fset, filename, src)
+	if err != nil {
+		log.Warnf("fileStats could not count exported symbols of file=%s, err=%v", filename, err)
+	}
+
+	return stats, nil
+}
+
+// exportedSymbols counts exported top level functions, types, vars, consts,
+// and exported methods of exported types.
+func exportedSymbols(fset *token.FileSet, filename string, src []byte) (int, error) {
+	f, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			if d.Recv != nil && !ast.IsExported(receiverName(d.Recv)) {
+				continue
+			}
+			count++
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch sp := spec.(type) {
+				case *ast.TypeSpec:
+					if sp.Name.IsExported() {
+						count++
+					}
+				case *ast.ValueSpec:
+					for _, name := range sp.Names {
+						if name.IsExported() {
+							count++
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return count, nil
+}
+
+// receiverName returns name of the receiver type, such as T of (t *T[K])
+func receiverName(recv *ast.FieldList) string {
+	if len(recv.List) == 0 {
+		return ""
+	}
+
+	expr := recv.List[0].Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
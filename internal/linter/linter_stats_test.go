@@ -0,0 +1,40 @@
+package linter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+func Test_fileStats(t *testing.T) {
+	got, err := fileStats("testdata/stats/stats.go")
+	if err != nil {
+		t.Fatalf("fileStats() error = %v", err)
+	}
+
+	// Kind, KindA, Raw, Kind.String and New
+	want := types.CodeStats{Lines: 29, Code: 14, Comments: 7, Blanks: 8, Exported: 5}
+	if got != want {
+		t.Errorf("fileStats() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_codeSize(t *testing.T) {
+	ctx := Context{Dir: "testdata/modules/sub", root: "testdata/modules"}
+
+	got, err := codeSize(ctx, []string{"testdata/modules/sub/sub.go"})
+	if err != nil {
+		t.Fatalf("codeSize() error = %v", err)
+	}
+
+	if len(got.Packages) != 1 || got.Packages[0].Dir != "sub" || got.Packages[0].Files != 1 {
+		t.Errorf("codeSize() packages = %+v, want one package in sub", got.Packages)
+	}
+	if len(got.Files) != 1 || got.Files[0].Filename != "sub/sub.go" {
+		t.Errorf("codeSize() files = %+v, want sub/sub.go", got.Files)
+	}
+	if !reflect.DeepEqual(got.CodeStats, got.Packages[0].CodeStats) {
+		t.Errorf("codeSize() total = %+v, want %+v", got.CodeStats, got.Packages[0].CodeStats)
+	}
+}
@@ -0,0 +1,450 @@
+package linter
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/yeqown/goreportcard/internal/types"
+	"github.com/yeqown/log"
+	"golang.org/x/mod/modfile"
+	gomodule "golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+var _ IDetailLinter = vuln{}
+
+// Reachability of vulnerable code, from the most to the least reachable.
+const (
+	reachSymbol  = "symbol"  // vulnerable symbols are referenced
+	reachPackage = "package" // vulnerable packages are imported, but not the symbols
+	reachModule  = "module"  // the module is only required
+)
+
+// _reachWeights are weights of reachability to reduce the percentage.
+var _reachWeights = map[string]float64{
+	reachSymbol:  1,
+	reachPackage: .5,
+	reachModule:  .2,
+}
+
+// vuln matches modules required by go.mod against a local copy of the Go
+// vulnerability database in OSV format, and finds whether vulnerable symbols
+// are referenced by the code. References are found syntactically rather than
+// by call graph, so they are approximate. Vulnerabilities of the standard
+// library are not checked, since the Go version to build is unknown.
+// The percentage is decided by the worst finding, see vulnPercentage, so
+// one reachable vulnerability is not diluted by many requirements.
+type vuln struct {
+	name   string  // linter's name
+	desc   string  // linter's desc
+	weight float64 // linter's weight
+
+	db string // dir of vulnerability database
+}
+
+func newVuln(opt *types.LinterOption) (ILinter, error) {
+	db, err := settingString(opt.Settings, "db", "")
+	if err != nil {
+		return nil, errors.Wrap(err, "vuln")
+	}
+	if db == "" {
+		return nil, errors.New("vuln: settings.db is required")
+	}
+
+	return vuln{
+		name:   opt.Name,
+		desc:   opt.Desc,
+		weight: opt.Weight,
+		db:     db,
+	}, nil
+}
+
+func (v vuln) Name() string {
+	return v.name
+}
+
+func (v vuln) Description() string {
+	return v.desc
+}
+
+func (v vuln) Weight() float64 {
+	return v.weight
+}
+
+func (v vuln) Execute(ctx Context) (float64, []types.FileSummary, error) {
+	score := types.Score{}
+	err := v.ExecuteDetail(ctx, &score)
+	return score.Percentage, score.Summaries, err
+}
+
+func (v vuln) ExecuteDetail(ctx Context, score *types.Score) error {
+	data, err := ioutil.ReadFile(filepath.Join(ctx.Dir, "go.mod"))
+	if os.IsNotExist(err) {
+		// not module aware, nothing to check
+		score.Percentage = 1
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "vuln.ReadFile")
+	}
+	f, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		return errors.Wrap(err, "vuln.Parse")
+	}
+
+	entries, err := loadOSV(v.db)
+	if err != nil {
+		return err
+	}
+	refs := collectRefs(ctx.Filenames)
+
+	var (
+		collector = newSummaryCollector(ctx)
+		found     []types.Vulnerability
+	)
+	for _, req := range f.Require {
+		version, ok := requiredVersion(f, req.Mod.Path, req.Mod.Version)
+		if !ok {
+			// replaced by local path
+			continue
+		}
+
+		for _, entry := range entries[req.Mod.Path] {
+			for _, affected := range entry.Affected {
+				if affected.Package.Name != req.Mod.Path || !affected.affects(version) {
+					continue
+				}
+
+				vul := types.Vulnerability{
+					ID:      entry.ID,
+					Aliases: entry.Aliases,
+					Summary: entry.Summary,
+					Module:  req.Mod.Path,
+					Version: version,
+					Fixed:   affected.fixed(version),
+				}
+				vul.Reachable, vul.Symbols = refs.reach(affected.EcosystemSpecific.Imports)
+				found = append(found, vul)
+
+				fixed := "no fixed version"
+				if vul.Fixed != "" {
+					fixed = "fixed in " + vul.Fixed
+				}
+				collector.add("go.mod", types.Error{
+					LineNumber:  req.Syntax.Start.Line,
+					Rule:        entry.ID,
+					Severity:    reachSeverity(vul.Reachable),
+					ErrorString: fmt.Sprintf("%s@%s: %s (%s, %s reachable)", vul.Module, version, vul.Summary, fixed, vul.Reachable),
+				})
+				break
+			}
+		}
+	}
+
+	sortVulnerabilities(found)
+	score.Summaries = collector.summaries()
+	score.Vulnerabilities = found
+	score.Percentage = vulnPercentage(score.Summaries)
+	return nil
+}
+
+// vulnPercentage is 1 - weight of the most reachable vulnerability in
+// summaries, weight is decided by severity of the issue, see reachSeverity.
+// It's also used to rescore, so suppressed vulnerabilities are not counted.
+func vulnPercentage(summaries []types.FileSummary) float64 {
+	worst := 0.0
+	for _, summary := range summaries {
+		for _, e := range summary.Errors {
+			for reachable, w := range _reachWeights {
+				if reachSeverity(reachable) == e.Severity && w > worst {
+					worst = w
+				}
+			}
+		}
+	}
+	return 1 - worst
+}
+
+// requiredVersion returns the version of module path which would be built,
+// ok is false if it's replaced by local path.
+func requiredVersion(f *modfile.File, path, version string) (string, bool) {
+	for _, r := range f.Replace {
+		if r.Old.Path != path || (r.Old.Version != "" && r.Old.Version != version) {
+			continue
+		}
+		if r.New.Version == "" || r.New.Path != path {
+			// local path, or the code is from another module
+			return "", false
+		}
+		return r.New.Version, true
+	}
+	return version, true
+}
+
+func reachSeverity(reachable string) string {
+	switch reachable {
+	case reachSymbol:
+		return "error"
+	case reachPackage:
+		return "warning"
+	}
+	return "info"
+}
+
+// osvEntry is a vulnerability in OSV format, only fields used are decoded.
+// See https://ossf.github.io/osv-schema/
+type osvEntry struct {
+	ID        string        `json:"id"`
+	Aliases   []string      `json:"aliases"`
+	Summary   string        `json:"summary"`
+	Withdrawn string        `json:"withdrawn"`
+	Affected  []osvAffected `json:"affected"`
+}
+
+type osvAffected struct {
+	Package struct {
+		Name      string `json:"name"`
+		Ecosystem string `json:"ecosystem"`
+	} `json:"package"`
+	Ranges []struct {
+		Type   string `json:"type"`
+		Events []struct {
+			Introduced string `json:"introduced"`
+			Fixed      string `json:"fixed"`
+		} `json:"events"`
+	} `json:"ranges"`
+	EcosystemSpecific struct {
+		Imports []osvImport `json:"imports"`
+	} `json:"ecosystem_specific"`
+}
+
+// osvImport is a vulnerable package, all symbols of it are vulnerable
+// if Symbols is empty.
+type osvImport struct {
+	Path    string   `json:"path"`
+	Symbols []string `json:"symbols"`
+}
+
+// affects reports whether version is in any SEMVER range of a. Versions of
+// OSV have no "v" prefix, and "0" of introduced means all versions.
+func (a osvAffected) affects(version string) bool {
+	for _, r := range a.Ranges {
+		if r.Type != "SEMVER" {
+			continue
+		}
+		affected := false
+		for _, e := range r.Events {
+			switch {
+			case e.Introduced != "":
+				if e.Introduced == "0" || semver.Compare(version, "v"+e.Introduced) >= 0 {
+					affected = true
+				}
+			case e.Fixed != "":
+				if semver.Compare(version, "v"+e.Fixed) >= 0 {
+					affected = false
+				}
+			}
+		}
+		if affected {
+			return true
+		}
+	}
+	return false
+}
+
+// fixed returns the lowest fixed version which is greater than version.
+func (a osvAffected) fixed(version string) string {
+	fixed := ""
+	for _, r := range a.Ranges {
+		for _, e := range r.Events {
+			if e.Fixed == "" || semver.Compare("v"+e.Fixed, version) <= 0 {
+				continue
+			}
+			if fixed == "" || semver.Compare("v"+e.Fixed, fixed) < 0 {
+				fixed = "v" + e.Fixed
+			}
+		}
+	}
+	return fixed
+}
+
+// loadOSV loads entries of Go ecosystem in db, key is module path. Entries are
+// in ID/*.json of the database (https://go.dev/security/vuln/database), or
+// *.json in db such as the export of osv.dev.
+func loadOSV(db string) (map[string][]osvEntry, error) {
+	dir := filepath.Join(db, "ID")
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		dir = db
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, errors.Wrap(err, "loadOSV.Glob")
+	}
+	if len(files) == 0 {
+		return nil, errors.Errorf("loadOSV: no entries in vulnerability database %s", db)
+	}
+
+	entries := make(map[string][]osvEntry, len(files))
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "loadOSV.ReadFile")
+		}
+		var entry osvEntry
+		if err = json.Unmarshal(data, &entry); err != nil {
+			log.Warnf("loadOSV skipped invalid entry=%s, err=%v", file, err)
+			continue
+		}
+		if entry.ID == "" || entry.Withdrawn != "" {
+			continue
+		}
+
+		modules := make(map[string]struct{}, len(entry.Affected))
+		for _, affected := range entry.Affected {
+			if affected.Package.Ecosystem != "Go" {
+				continue
+			}
+			if _, ok := modules[affected.Package.Name]; ok {
+				continue
+			}
+			modules[affected.Package.Name] = struct{}{}
+			entries[affected.Package.Name] = append(entries[affected.Package.Name], entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// codeRefs are imported packages and referenced symbols of the code.
+type codeRefs struct {
+	imports   map[string]struct{}            // import paths
+	symbols   map[string]map[string]struct{} // map[import path]symbols, such as Parse
+	selectors map[string]struct{}            // all selected names, methods are matched by them
+}
+
+// collectRefs collects imports and selector expressions of files, files
+// which could not be parsed are ignored.
+func collectRefs(filenames []string) codeRefs {
+	refs := codeRefs{
+		imports:   make(map[string]struct{}, 64),
+		symbols:   make(map[string]map[string]struct{}, 64),
+		selectors: make(map[string]struct{}, 256),
+	}
+
+	fset := token.NewFileSet()
+	for _, filename := range filenames {
+		f, err := parser.ParseFile(fset, filename, nil, parser.SkipObjectResolution)
+		if err != nil {
+			log.Warnf("collectRefs failed to parse file=%s, err=%v", filename, err)
+			continue
+		}
+
+		names := make(map[string]string, len(f.Imports)) // map[local name]import path
+		for _, spec := range f.Imports {
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			refs.imports[importPath] = struct{}{}
+			name := importName(importPath)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			names[name] = importPath
+		}
+
+		ast.Inspect(f, func(n ast.Node) bool {
+			sel, ok := n.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			refs.selectors[sel.Sel.Name] = struct{}{}
+			if x, ok := sel.X.(*ast.Ident); ok {
+				if importPath, ok := names[x.Name]; ok {
+					if refs.symbols[importPath] == nil {
+						refs.symbols[importPath] = make(map[string]struct{}, 8)
+					}
+					refs.symbols[importPath][sel.Sel.Name] = struct{}{}
+				}
+			}
+			return true
+		})
+	}
+
+	return refs
+}
+
+// importName is the default name of imported package, it's the last element
+// of importPath without the major version suffix, such as y of
+// "github.com/x/y/v2" and yaml of "gopkg.in/yaml.v2".
+func importName(importPath string) string {
+	if prefix, _, ok := gomodule.SplitPathVersion(importPath); ok {
+		importPath = prefix
+	}
+	return importPath[strings.LastIndex(importPath, "/")+1:]
+}
+
+// reach returns the reachability of vulnerable imports, and the referenced
+// vulnerable symbols. Method "T.M" is referenced if M is selected by any
+// code, since the type of receiver is unknown without type checking.
+func (r codeRefs) reach(imports []osvImport) (string, []string) {
+	reachable := reachModule
+	var symbols []string
+	for _, imp := range imports {
+		if _, ok := r.imports[imp.Path]; !ok {
+			continue
+		}
+		if len(imp.Symbols) == 0 {
+			// the whole package is vulnerable
+			reachable = reachSymbol
+			continue
+		}
+		if reachable == reachModule {
+			reachable = reachPackage
+		}
+
+		for _, symbol := range imp.Symbols {
+			var referenced bool
+			if i := strings.Index(symbol, "."); i >= 0 {
+				_, referenced = r.selectors[symbol[i+1:]]
+			} else {
+				_, referenced = r.symbols[imp.Path][symbol]
+			}
+			if !referenced {
+				continue
+			}
+			symbols = append(symbols, imp.Path+"."+symbol)
+			reachable = reachSymbol
+		}
+	}
+
+	return reachable, symbols
+}
+
+// sortVulnerabilities sorts the most reachable first.
+func sortVulnerabilities(found []types.Vulnerability) {
+	sort.SliceStable(found, func(i, j int) bool {
+		return _reachWeights[found[i].Reachable] > _reachWeights[found[j].Reachable]
+	})
+}
+
+// takeVulnerabilities moves vulnerabilities out of scores, since they are
+// reported at the top of the result, not the detail of the check.
+func takeVulnerabilities(scores []types.Score) []types.Vulnerability {
+	var found []types.Vulnerability
+	for i := range scores {
+		found = append(found, scores[i].Vulnerabilities...)
+		scores[i].Vulnerabilities = nil
+	}
+	return found
+}
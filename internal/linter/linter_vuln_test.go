@@ -0,0 +1,145 @@
+package linter
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/yeqown/goreportcard/internal/types"
+)
+
+func Test_osvAffected_affects(t *testing.T) {
+	entries, err := loadOSV("testdata/vulndb")
+	if err != nil {
+		t.Fatalf("loadOSV() error = %v", err)
+	}
+	affected := entries["example.com/old"][0].Affected[0]
+
+	tests := []struct {
+		name    string
+		version string
+		want    bool
+		fixed   string
+	}{
+		{name: "case 1", version: "v1.0.0", want: true, fixed: "v1.0.1"},
+		{name: "case 2", version: "v1.0.1", want: false, fixed: "v1.1.2"},
+		{name: "case 3", version: "v1.1.1", want: true, fixed: "v1.1.2"},
+		{name: "case 4", version: "v1.1.2", want: false, fixed: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := affected.affects(tt.version); got != tt.want {
+				t.Errorf("affects(%s) = %v, want %v", tt.version, got, tt.want)
+			}
+			if got := affected.fixed(tt.version); got != tt.fixed {
+				t.Errorf("fixed(%s) = %v, want %v", tt.version, got, tt.fixed)
+			}
+		})
+	}
+}
+
+func Test_vuln_ExecuteDetail(t *testing.T) {
+	dir := "testdata/vuln"
+	l, err := newVuln(&types.LinterOption{
+		Name:     "vuln",
+		Settings: map[string]interface{}{"db": "testdata/vulndb"},
+	})
+	if err != nil {
+		t.Fatalf("newVuln() error = %v", err)
+	}
+
+	ctx := Context{
+		Dir:       dir,
+		Filenames: []string{filepath.Join(dir, "main.go")},
+		Branch:    types.MasterBranch,
+	}
+	score := types.Score{}
+	if err = l.(vuln).ExecuteDetail(ctx, &score); err != nil {
+		t.Fatalf("ExecuteDetail() error = %v", err)
+	}
+
+	want := []types.Vulnerability{
+		{ID: "GO-2099-0001", Aliases: []string{"CVE-2099-0001"}, Summary: "Panic on crafted input in example.com/old",
+			Module: "example.com/old", Version: "v1.0.0", Fixed: "v1.0.1", Reachable: reachSymbol,
+			Symbols: []string{"example.com/old/parse.Parse"}},
+		{ID: "GO-2099-0004", Summary: "Unsafe call in example.com/pkg",
+			Module: "example.com/pkg", Version: "v0.5.0", Reachable: reachPackage},
+		{ID: "GO-2099-0005", Summary: "Required but not imported",
+			Module: "example.com/unused", Version: "v0.1.0", Fixed: "v0.2.0", Reachable: reachModule},
+	}
+	if !reflect.DeepEqual(score.Vulnerabilities, want) {
+		t.Errorf("ExecuteDetail() vulnerabilities = %+v, want %+v", score.Vulnerabilities, want)
+	}
+
+	var severities []string
+	for _, summary := range score.Summaries {
+		for _, e := range summary.Errors {
+			severities = append(severities, e.Rule+":"+e.Severity)
+		}
+	}
+	wantSeverities := []string{"GO-2099-0001:error", "GO-2099-0004:warning", "GO-2099-0005:info"}
+	if !reflect.DeepEqual(severities, wantSeverities) {
+		t.Errorf("ExecuteDetail() severities = %v, want %v", severities, wantSeverities)
+	}
+
+	// the worst is reachable by symbol
+	if score.Percentage != 0 {
+		t.Errorf("ExecuteDetail() percentage = %v, want 0", score.Percentage)
+	}
+
+	// suppressing the worst, the next is reachable by package
+	summaries := []types.FileSummary{{Filename: "go.mod"}}
+	for _, e := range score.Summaries[0].Errors {
+		if e.Rule != "GO-2099-0001" {
+			summaries[0].Errors = append(summaries[0].Errors, e)
+		}
+	}
+	if !rescorable(l) {
+		t.Fatalf("vuln should be rescorable")
+	}
+	p, err := rescore(ctx, l, summaries)
+	if err != nil {
+		t.Fatalf("rescore() error = %v", err)
+	}
+	if math.Abs(p-.5) > 1e-9 {
+		t.Errorf("rescore() = %v, want 0.5", p)
+	}
+}
+
+func Test_collectRefs_versioned(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"main.go": `package main
+
+import (
+	"example.com/x/y/v2"
+	"example.com/x/y/v2/sub"
+	"gopkg.in/yaml.v2"
+)
+
+func main() {
+	y.Do()
+	sub.Run()
+	yaml.Unmarshal(nil, nil)
+}
+`,
+	})
+	refs := collectRefs([]string{filepath.Join(dir, "main.go")})
+
+	tests := []struct {
+		name       string
+		importPath string
+		symbol     string
+	}{
+		{name: "case 1", importPath: "example.com/x/y/v2", symbol: "Do"},
+		{name: "case 2", importPath: "example.com/x/y/v2/sub", symbol: "Run"},
+		{name: "case 3", importPath: "gopkg.in/yaml.v2", symbol: "Unmarshal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := refs.symbols[tt.importPath][tt.symbol]; !ok {
+				t.Errorf("collectRefs() symbols of %s = %v, want %s", tt.importPath, refs.symbols[tt.importPath], tt.symbol)
+			}
+		})
+	}
+}
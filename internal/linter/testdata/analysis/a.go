@@ -0,0 +1,12 @@
+package analysis
+
+import "strconv"
+
+func parse(s string) int {
+	n, err := strconv.Atoi(s)
+	n = 0
+	if err != nil {
+		return -1
+	}
+	return n
+}
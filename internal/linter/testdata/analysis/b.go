@@ -0,0 +1,5 @@
+package analysis
+
+func double(n int) int {
+	return n * 2
+}
@@ -0,0 +1,3 @@
+module example.com/analysis
+
+go 1.14
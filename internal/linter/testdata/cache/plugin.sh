@@ -0,0 +1,5 @@
+#!/bin/sh
+# appends stdin to the file $1, and reports the first line of a/a.go.
+cat >> "$1"
+echo >> "$1"
+echo '[{"filename": "a/a.go", "errors": [{"line_number": 1, "error_string": "banned"}]}]'
@@ -0,0 +1,11 @@
+module example.com/deps
+
+go 1.22
+
+require (
+	example.com/fine v1.2.0
+	example.com/local v1.0.0
+	example.com/old v1.0.0 // indirect
+)
+
+replace example.com/local => ../local
@@ -0,0 +1,20 @@
+// Package doc is the testdata of doc check.
+package doc
+
+// Client talks to the server.
+type Client struct{}
+
+// Do sends a request.
+func (c *Client) Do() {}
+
+// sends a request without retry.
+func (c *Client) DoOnce() {}
+
+func (c *Client) Close() {}
+
+func (c *client) Hidden() {}
+
+type client struct{}
+
+// A Server serves clients.
+type Server struct{}
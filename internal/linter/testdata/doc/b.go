@@ -0,0 +1,18 @@
+package doc
+
+// Modes of Client.
+const (
+	ModeA = iota
+	ModeB
+)
+
+// MaxSize is the max size.
+const MaxSize = 10
+
+const MinSize = 1
+
+// Version is not checked, since vars are not counted.
+var Version = "v1"
+
+// NewClientX creates a Client.
+func NewClient() *Client { return nil }
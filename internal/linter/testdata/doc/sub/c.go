@@ -0,0 +1,4 @@
+package sub
+
+// Helper helps.
+func Helper() {}
@@ -0,0 +1,16 @@
+package duplicate
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		if v < 0 {
+			continue
+		}
+		total += v * 2
+	}
+	return total
+}
+
+func other(s string) string {
+	return s + "!"
+}
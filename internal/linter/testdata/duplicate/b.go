@@ -0,0 +1,21 @@
+package duplicate
+
+import "fmt"
+
+// renamed copy of sum
+func count(items []int) int {
+	n := 0
+	for _, item := range items {
+		if item < 0 {
+			continue
+		}
+		n += item * 2
+	}
+	return n
+}
+
+func print(names []string) {
+	for i, name := range names {
+		fmt.Println(i, name)
+	}
+}
@@ -0,0 +1,12 @@
+package duplicate
+
+func product(values []int) int {
+	total := 1
+	for _, v := range values {
+		if v == 0 {
+			return 0
+		}
+		total *= v
+	}
+	return total
+}
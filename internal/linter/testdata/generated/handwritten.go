@@ -0,0 +1,3 @@
+// generated values are cached here, edit with care.
+
+package generated
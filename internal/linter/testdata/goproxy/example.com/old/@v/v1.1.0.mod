@@ -0,0 +1,6 @@
+// Deprecated: use example.com/old/v2 instead.
+module example.com/old
+
+go 1.22
+
+retract v1.0.0 // data race in Client
@@ -0,0 +1,3 @@
+#!/bin/sh
+# prints output of gosec, __DIR__ is replaced by the working dir.
+sed "s#__DIR__#$PWD#g" "$(dirname "$0")/output.json"
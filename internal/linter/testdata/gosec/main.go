@@ -0,0 +1,9 @@
+package main
+
+import "os"
+
+const password = "hunter2"
+
+func main() {
+	os.Remove(password)
+}
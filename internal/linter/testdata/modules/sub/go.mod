@@ -0,0 +1,3 @@
+module example.com/root/sub
+
+go 1.22
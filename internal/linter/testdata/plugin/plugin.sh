@@ -0,0 +1,11 @@
+#!/bin/sh
+# reports the first line of every file listed in stdin,
+# or sleeps if $1 is "sleep", or fails if $1 is "fail".
+case "$1" in
+sleep) sleep 10 ;;
+fail) echo "bad config" >&2; exit 2 ;;
+esac
+
+cat > /dev/null
+echo '[{"filename": "a.go", "errors": [{"line_number": 1, "error_string": "banned"}]},
+{"filename": "other.go", "errors": [{"line_number": 1, "error_string": "not linted"}]}]'
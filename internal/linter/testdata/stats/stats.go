@@ -0,0 +1,29 @@
+// Package stats is used to test code size statistics.
+package stats
+
+/*
+Kind is
+a kind.
+*/
+type Kind int
+
+// Kinds
+const (
+	KindA Kind = iota // A
+	kindB
+)
+
+var Raw = `line 1
+line 2`
+
+type point struct{}
+
+// Name is exported, but point is not.
+func (p *point) Name() string { return "" }
+
+func (k Kind) String() string {
+
+	return "kind"
+}
+
+func New() Kind { return KindA }
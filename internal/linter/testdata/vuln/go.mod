@@ -0,0 +1,10 @@
+module example.com/vuln
+
+go 1.22
+
+require (
+	example.com/fine v1.2.0
+	example.com/old v1.0.0
+	example.com/pkg v0.5.0
+	example.com/unused v0.1.0
+)
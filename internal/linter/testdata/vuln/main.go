@@ -0,0 +1,11 @@
+package main
+
+import (
+	"example.com/old/parse"
+	"example.com/pkg/x"
+)
+
+func main() {
+	parse.Parse("a")
+	x.Safe()
+}
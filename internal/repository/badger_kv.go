@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	badger "github.com/dgraph-io/badger/v2"
 	"github.com/pkg/errors"
 	"github.com/yeqown/log"
@@ -12,7 +14,7 @@ type badgerRepo struct {
 
 // NewBadgerRepo .
 func NewBadgerRepo(dir string) (IRepository, error) {
-	db, err := badger.Open(badger.DefaultOptions("./.badger"))
+	db, err := badger.Open(badger.DefaultOptions(dir))
 	if err != nil {
 		log.Errorf("repository.NewBadgerRepo failed to load db, err=%v", err)
 		return nil, errors.Wrap(err, "failed to load db")
@@ -61,6 +63,16 @@ func (br badgerRepo) Update(key, value []byte) (err error) {
 	return nil
 }
 
+func (br badgerRepo) UpdateWithTTL(key, value []byte, ttl time.Duration) (err error) {
+	if err = br.DB.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(badger.NewEntry(key, value).WithTTL(ttl))
+	}); err != nil {
+		return errors.Wrap(err, "badgerRepo.UpdateWithTTL")
+	}
+
+	return nil
+}
+
 func (br badgerRepo) Close() {
 	br.DB.Close()
 }
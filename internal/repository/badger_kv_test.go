@@ -6,7 +6,12 @@ import (
 )
 
 func Test_badgerRepo_Get(t *testing.T) {
-	br, _ := NewBadgerRepo("./.badger")
+	br, err := NewBadgerRepo(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewBadgerRepo() error = %v", err)
+	}
+	defer br.Close()
+
 	key := []byte("key")
 	val := []byte("val")
 
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/go-redis/redis"
 )
 
@@ -38,6 +40,10 @@ func (rd redisRepo) Update(key, value []byte) error {
 	return rd.Client.Set(string(key), value, 0).Err()
 }
 
+func (rd redisRepo) UpdateWithTTL(key, value []byte, ttl time.Duration) error {
+	return rd.Client.Set(string(key), value, ttl).Err()
+}
+
 func (rd redisRepo) Close() {
 	rd.Client.Close()
 }
@@ -3,8 +3,10 @@ package repository
 import (
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/yeqown/log"
 )
 
 // IRepository .
@@ -13,6 +15,9 @@ type IRepository interface {
 
 	Update(key, value []byte) error
 
+	// UpdateWithTTL updates key which expires after ttl
+	UpdateWithTTL(key, value []byte, ttl time.Duration) error
+
 	Close()
 }
 
@@ -34,6 +39,10 @@ var (
 
 	// default path to save bader
 	_defaultBadgerDBPath = ".badger"
+
+	// _legacyBadgerDBPath is where badger was saved by older versions,
+	// relative to working dir.
+	_legacyBadgerDBPath = ".badger"
 )
 
 func init() {
@@ -47,11 +56,11 @@ func Init(db DBType) (err error) {
 	case Redis:
 		_repo, err = NewRedisRepo()
 	case Badger:
-		_repo, err = NewBadgerRepo(_defaultBadgerDBPath)
+		_repo, err = NewBadgerRepo(badgerDBPath(_defaultBadgerDBPath, _legacyBadgerDBPath))
 	case Unknown:
 		fallthrough
 	default:
-		_repo, err = NewBadgerRepo(_defaultBadgerDBPath)
+		_repo, err = NewBadgerRepo(badgerDBPath(_defaultBadgerDBPath, _legacyBadgerDBPath))
 	}
 
 	if err != nil {
@@ -61,6 +70,21 @@ func Init(db DBType) (err error) {
 	return nil
 }
 
+// badgerDBPath returns legacy rather than path, if there is only a database
+// saved in legacy by older versions, so that reports and high scores are kept
+// after upgrading.
+func badgerDBPath(path, legacy string) string {
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	if _, err := os.Stat(filepath.Join(legacy, "MANIFEST")); err != nil {
+		return path
+	}
+
+	log.Warnf("repository: badger is loaded from legacy path=%s, move it to %s to use the new path", legacy, path)
+	return legacy
+}
+
 // GetRepo .
 func GetRepo() IRepository {
 	return _repo
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_badgerDBPath(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "home", ".badger")
+	legacy := filepath.Join(dir, "work", ".badger")
+
+	// case 0: no database
+	if got := badgerDBPath(path, legacy); got != path {
+		t.Errorf("case 0: badgerDBPath() = %v, want %v", got, path)
+	}
+
+	// case 1: only legacy database
+	if err := os.MkdirAll(legacy, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(legacy, "MANIFEST"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := badgerDBPath(path, legacy); got != legacy {
+		t.Errorf("case 1: badgerDBPath() = %v, want %v", got, legacy)
+	}
+
+	// case 2: both, the new one is used
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got := badgerDBPath(path, legacy); got != path {
+		t.Errorf("case 2: badgerDBPath() = %v, want %v", got, path)
+	}
+}
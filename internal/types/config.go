@@ -1,13 +1,16 @@
 package types
 
 import (
+	"math"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/yeqown/goreportcard/internal/repository"
 	vcshelper "github.com/yeqown/goreportcard/internal/vcs-helper"
 
 	"github.com/BurntSushi/toml"
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/pkg/errors"
 )
 
@@ -27,6 +30,10 @@ var (
 		RepoRoot: "goreportcard-repos/",
 		Domain:   "http://localhost:8000",
 		SkipDirs: []string{},
+		Linters:  _defaultLinters,
+		Tests: TestsOption{
+			Linters: []string{"gofmt", "govet", "errcheck"},
+		},
 		URIFormatRules: []uriFormatRule{
 			{
 				Prefix:    "github.com",
@@ -41,6 +48,67 @@ func init() {
 	_defaultConfig.RepoRoot = filepath.Join(home, _defaultConfig.RepoRoot)
 }
 
+// _defaultLinters would be used if no linters configured.
+// linters: https://golangci-lint.run/usage/linters/
+var _defaultLinters = []*LinterOption{
+	{
+		Name: "gofmt", Type: NativeLinter, Weight: .30,
+		Desc: "Gofmt formats Go programs. We run gofmt on your code, and show the diff of files which are not formatted.",
+	}, // gofmt
+	{
+		Name: "govet", Weight: .30,
+		Desc: "Vet examines Go source code and reports suspicious constructs, such as Printf calls whose arguments do not align with the format string.",
+	}, // govet
+	{
+		Name: "errcheck", Weight: .10,
+		Desc: "Errcheck is a program for checking for unchecked errors in go programs. These unchecked errors can be critical bugs in some cases.",
+	}, // errcheck
+	{
+		Name: "ineffassign", Weight: .05,
+		Desc: "Detects when assignments to existing variables are not used.",
+	}, // ineffassign
+	{
+		Name: "deadcode", Weight: .05,
+		Desc: "Finds unused code",
+	}, // deadcode
+	{
+		Name: "gosimple", Weight: .05,
+		Desc: "Linter for Go source code that specializes in simplifying a code.",
+	}, // gosimple
+	{
+		Name: "staticcheck", Weight: .05,
+		Desc: "Staticcheck is a go vet on steroids, applying a ton of static analysis checks.",
+	}, // staticcheck
+	{
+		Name: "structcheck", Weight: .05,
+		Desc: "Finds unused struct fields.",
+	}, // structcheck
+	{
+		Name: "unused", Weight: .10,
+		Desc: "Scores Go code for unused constants, variables, functions and types.",
+	}, // unused
+	{
+		Name: "varcheck", Weight: .05,
+		Desc: "Finds unused global variables and constants.",
+	}, // varcheck
+	{
+		Name: "typecheck", Weight: .05,
+		Desc: "Like the front-end of a Go compiler, parses and type-checks Go codes.",
+	}, // typecheck
+	{
+		Name: "funlen", Weight: .10,
+		Desc: "Tool for detection of long functions.",
+	}, // funlen
+	{
+		Name: "lll", Weight: .10,
+		Desc: "Reports long lines.",
+	}, // lll
+	{
+		Name: "nestif", Weight: .15,
+		Desc: "Reports deeply nested if statements.",
+	}, // nestif
+}
+
 // GetConfig get global config
 func GetConfig() *Config {
 	if _cfg == nil {
@@ -66,7 +134,11 @@ func Init(confPath string) error {
 		return errors.Wrap(err, "types.Init.DecodeFile")
 	}
 
-	return nil
+	if len(_cfg.Linters) == 0 {
+		_cfg.Linters = _defaultLinters
+	}
+
+	return errors.Wrap(_cfg.Validate(), "types.Init.Validate")
 }
 
 func writeConfig(confPath string, cfg *Config) error {
@@ -88,12 +160,231 @@ type Config struct {
 	Domain     string                 `toml:"domain"`
 
 	// lint options
-	SkipDirs []string `toml:"skipDirs"`
+	SkipDirs      []string         `toml:"skipDirs"` // doublestar globs of paths relative to root of repo
+	Linters       []*LinterOption  `toml:"linters"`
+	Scoring       ScoringOption    `toml:"scoring"`
+	Timeout       string           `toml:"timeout,omitempty"`       // deadline of linting one repo, such as "20m"
+	LinterTimeout string           `toml:"linterTimeout,omitempty"` // default deadline of each linter
+	RepoConfig    RepoConfigOption `toml:"repoConfig"`
+	Tests         TestsOption      `toml:"tests"`
 
 	// report options
 	URIFormatRules []uriFormatRule `toml:"uriFormatRules"`
 }
 
+// LinterType decides how a linter would be executed.
+type LinterType string
+
+const (
+	// GolangciLinter runs by golangci-lint, it's the default type.
+	GolangciLinter LinterType = "golangci"
+	// AnalysisLinter runs go/analysis analyzers in process, no need golangci-lint.
+	AnalysisLinter LinterType = "analysis"
+	// NativeLinter is implemented by goreportcard itself, such as gofmt.
+	NativeLinter LinterType = "native"
+	// PluginLinter is an external executable which is declared by Command,
+	// it speaks JSON in stdin and stdout, see linter.plugin.
+	PluginLinter LinterType = "plugin"
+)
+
+// LinterOption to enable or disable a linter and set its weight, description.
+// Settings would be passed to golangci-lint as `linters-settings.<Name>`,
+// for example: `line-length = 120` for lll.
+type LinterOption struct {
+	Name     string                 `toml:"name"`
+	Type     LinterType             `toml:"type,omitempty"`
+	Disabled bool                   `toml:"disabled"`
+	Weight   float64                `toml:"weight"`
+	Desc     string                 `toml:"description"`
+	Settings map[string]interface{} `toml:"settings,omitempty"`
+	Timeout  string                 `toml:"timeout,omitempty"` // overrides linterTimeout of config
+	Command  []string               `toml:"command,omitempty"` // executable and arguments of plugin linter
+}
+
+// TestsOption decides how test files are linted, they are linted in a separate
+// pass by Linters, and the scores are reported as "test code".
+type TestsOption struct {
+	Linters []string `toml:"linters"` // names of enabled linters, test files are not linted if empty
+	Graded  bool     `toml:"graded"`  // scores of test code are counted in grade or not
+}
+
+// RepoConfigOption limits what `.goreportcard.yml` in the root of repos
+// could do, nothing is allowed if it's not configured.
+type RepoConfigOption struct {
+	AllowExcludes     bool    `toml:"allowExcludes"`
+	AllowSuppressions bool    `toml:"allowSuppressions"`
+	MinWeight         float64 `toml:"minWeight"` // overridden weights are limited in [minWeight, maxWeight],
+	MaxWeight         float64 `toml:"maxWeight"` // and weights could not be overridden if maxWeight is 0
+}
+
+// ScoringStrategy decides how the percentage of a linter is calculated
+// from its issues.
+type ScoringStrategy string
+
+const (
+	// FileRatioScoring is the share of files without any issue, it's the default.
+	FileRatioScoring ScoringStrategy = "file-ratio"
+	// IssuesPerKLOCScoring decreases the percentage with issues per 1000 lines.
+	IssuesPerKLOCScoring ScoringStrategy = "issues-per-kloc"
+	// SeverityWeightedScoring is like IssuesPerKLOCScoring, but issues are
+	// weighted by their severity.
+	SeverityWeightedScoring ScoringStrategy = "severity-weighted"
+)
+
+// ScoringOption chooses the scoring strategy of linters.
+// Limit is the count of issues per 1000 lines which scores 0, and
+// Severities are weights of severities for severity-weighted strategy,
+// for example: `warning = 0.5`.
+type ScoringOption struct {
+	Strategy   ScoringStrategy    `toml:"strategy"`
+	Limit      float64            `toml:"limit,omitempty"`
+	Severities map[string]float64 `toml:"severities,omitempty"`
+}
+
+// Validate checks the config is valid or not, for now,
+// only linters and scoring would be checked.
+func (c *Config) Validate() error {
+	var (
+		enabled int
+		names   = make(map[string]struct{}, len(c.Linters))
+	)
+
+	for idx, opt := range c.Linters {
+		if opt.Name == "" {
+			return errors.Errorf("linters[%d]: name is required", idx)
+		}
+		if _, ok := names[opt.Name]; ok {
+			return errors.Errorf("linters[%d]: duplicated linter %q", idx, opt.Name)
+		}
+		names[opt.Name] = struct{}{}
+
+		switch opt.Type {
+		case "", GolangciLinter, AnalysisLinter, NativeLinter:
+		case PluginLinter:
+			if len(opt.Command) == 0 || opt.Command[0] == "" {
+				return errors.Errorf("linters[%d]: command of plugin %q is required", idx, opt.Name)
+			}
+		default:
+			return errors.Errorf("linters[%d]: unknown type %q of %q", idx, opt.Type, opt.Name)
+		}
+
+		if _, err := time.ParseDuration(opt.Timeout); opt.Timeout != "" && err != nil {
+			return errors.Errorf("linters[%d]: invalid timeout %q of %q", idx, opt.Timeout, opt.Name)
+		}
+
+		if opt.Disabled {
+			continue
+		}
+		enabled++
+
+		// NaN and Inf also are invalid weight
+		if !(opt.Weight > 0) || math.IsInf(opt.Weight, 0) {
+			return errors.Errorf("linters[%d]: weight of %q is missing or invalid (%v), "+
+				"it must be a number greater than 0", idx, opt.Name, opt.Weight)
+		}
+	}
+
+	if enabled == 0 {
+		return errors.New("linters: at least one linter should be enabled")
+	}
+
+	if _, err := time.ParseDuration(c.Timeout); c.Timeout != "" && err != nil {
+		return errors.Errorf("timeout: invalid duration %q", c.Timeout)
+	}
+	if _, err := time.ParseDuration(c.LinterTimeout); c.LinterTimeout != "" && err != nil {
+		return errors.Errorf("linterTimeout: invalid duration %q", c.LinterTimeout)
+	}
+
+	for idx, pattern := range c.SkipDirs {
+		if !doublestar.ValidatePattern(pattern) {
+			return errors.Errorf("skipDirs[%d]: invalid glob %q", idx, pattern)
+		}
+	}
+
+	for idx, name := range c.Tests.Linters {
+		if _, ok := names[name]; !ok {
+			return errors.Errorf("tests.linters[%d]: linter %q is not configured", idx, name)
+		}
+	}
+
+	if err := c.RepoConfig.validate(); err != nil {
+		return err
+	}
+
+	return c.Scoring.validate()
+}
+
+// AllowWeights reports whether weights could be overridden
+func (o RepoConfigOption) AllowWeights() bool {
+	return o.MaxWeight != 0
+}
+
+func (o RepoConfigOption) validate() error {
+	if !o.AllowWeights() {
+		// bounds are useless if weights could not be overridden
+		return nil
+	}
+
+	if !(o.MinWeight > 0) || !(o.MaxWeight >= o.MinWeight) || math.IsInf(o.MaxWeight, 0) {
+		return errors.Errorf("repoConfig: weight range [%v, %v] is invalid, "+
+			"it must be 0 < minWeight <= maxWeight", o.MinWeight, o.MaxWeight)
+	}
+
+	return nil
+}
+
+const (
+	_defaultTimeout       = 20 * time.Minute
+	_defaultLinterTimeout = 10 * time.Minute
+)
+
+// ReportTimeout returns the deadline of linting one repo.
+func (c *Config) ReportTimeout() time.Duration {
+	return parseDuration(c.Timeout, _defaultTimeout)
+}
+
+// TimeoutOf returns the deadline of the linter, timeout of linter
+// overrides linterTimeout of config.
+func (c *Config) TimeoutOf(name string) time.Duration {
+	timeout := parseDuration(c.LinterTimeout, _defaultLinterTimeout)
+	for _, opt := range c.Linters {
+		if opt.Name == name {
+			return parseDuration(opt.Timeout, timeout)
+		}
+	}
+
+	return timeout
+}
+
+// parseDuration parses s as time.Duration, def would be returned
+// if s is empty or invalid.
+func parseDuration(s string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
+
+func (o ScoringOption) validate() error {
+	switch o.Strategy {
+	case "", FileRatioScoring, IssuesPerKLOCScoring, SeverityWeightedScoring:
+	default:
+		return errors.Errorf("scoring: unknown strategy %q", o.Strategy)
+	}
+
+	if o.Limit < 0 || math.IsNaN(o.Limit) || math.IsInf(o.Limit, 0) {
+		return errors.Errorf("scoring: limit is invalid (%v)", o.Limit)
+	}
+	for severity, weight := range o.Severities {
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return errors.Errorf("scoring: weight of severity %q is invalid (%v)", severity, weight)
+		}
+	}
+
+	return nil
+}
+
 type uriFormatRule struct {
 	Prefix    string `toml:"prefix"`
 	URIFormat string `toml:"uriFormat"`
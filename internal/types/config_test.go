@@ -0,0 +1,163 @@
+package types
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		linters    []*LinterOption
+		scoring    ScoringOption
+		repoConfig RepoConfigOption
+		tests      TestsOption
+		wantErr    bool
+	}{
+		{
+			name:    "case 0",
+			linters: _defaultLinters,
+			wantErr: false,
+		},
+		{
+			name: "case 1",
+			linters: []*LinterOption{
+				{Name: "lll"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "case 2",
+			linters: []*LinterOption{
+				{Name: "lll", Weight: math.NaN()},
+			},
+			wantErr: true,
+		},
+		{
+			name: "case 3",
+			linters: []*LinterOption{
+				{Name: "lll", Weight: .1},
+				{Name: "lll", Weight: .1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "case 4",
+			linters: []*LinterOption{
+				{Name: "lll", Weight: .1, Disabled: true},
+			},
+			wantErr: true,
+		},
+		{
+			name: "case 5",
+			linters: []*LinterOption{
+				{Name: "lll", Weight: .1, Settings: map[string]interface{}{"line-length": 120}},
+				{Name: "funlen", Disabled: true},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "case 6",
+			linters: _defaultLinters,
+			scoring: ScoringOption{Strategy: "unknown"},
+			wantErr: true,
+		},
+		{
+			name:    "case 7",
+			linters: _defaultLinters,
+			scoring: ScoringOption{Strategy: IssuesPerKLOCScoring, Limit: -1},
+			wantErr: true,
+		},
+		{
+			name:    "case 8",
+			linters: []*LinterOption{{Name: "lll", Weight: .1, Timeout: "1x"}},
+			wantErr: true,
+		},
+		{
+			name:    "case 9",
+			linters: _defaultLinters,
+			scoring: ScoringOption{
+				Strategy:   SeverityWeightedScoring,
+				Limit:      20,
+				Severities: map[string]float64{"warning": .5},
+			},
+			wantErr: false,
+		},
+		{
+			name:       "case 10",
+			linters:    _defaultLinters,
+			repoConfig: RepoConfigOption{MinWeight: 0, MaxWeight: 1},
+			wantErr:    true,
+		},
+		{
+			name:       "case 11",
+			linters:    _defaultLinters,
+			repoConfig: RepoConfigOption{MinWeight: .01, MaxWeight: 1},
+			wantErr:    false,
+		},
+		{
+			name:    "case 12",
+			linters: _defaultLinters,
+			tests:   TestsOption{Linters: []string{"unknown"}},
+			wantErr: true,
+		},
+		{
+			name:    "case 13",
+			linters: []*LinterOption{{Name: "banned", Type: PluginLinter, Weight: .1}},
+			wantErr: true,
+		},
+		{
+			name:    "case 14",
+			linters: []*LinterOption{{Name: "banned", Type: PluginLinter, Weight: .1, Command: []string{"banned"}}},
+			wantErr: false,
+		},
+		{
+			name:       "case 15",
+			linters:    _defaultLinters,
+			repoConfig: RepoConfigOption{MinWeight: 2},
+			wantErr:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{Linters: tt.linters, Scoring: tt.scoring, RepoConfig: tt.repoConfig, Tests: tt.tests}
+			if err := c.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_TimeoutOf(t *testing.T) {
+	c := &Config{
+		LinterTimeout: "1m",
+		Linters: []*LinterOption{
+			{Name: "coverage", Timeout: "15m"},
+			{Name: "lll", Timeout: "invalid"},
+			{Name: "govet"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		linter string
+		want   time.Duration
+	}{
+		{name: "case 0", linter: "coverage", want: 15 * time.Minute},
+		{name: "case 1", linter: "lll", want: time.Minute},
+		{name: "case 2", linter: "govet", want: time.Minute},
+		{name: "case 3", linter: "unknown", want: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.TimeoutOf(tt.linter); got != tt.want {
+				t.Errorf("Config.TimeoutOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := c.ReportTimeout(); got != _defaultTimeout {
+		t.Errorf("Config.ReportTimeout() = %v, want %v", got, _defaultTimeout)
+	}
+}
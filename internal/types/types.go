@@ -5,8 +5,54 @@ import "time"
 // Error contains the line number and the reason for
 // an error output from a command
 type Error struct {
-	LineNumber  int    `json:"line_number"`
-	ErrorString string `json:"error_string"`
+	LineNumber  int          `json:"line_number"`
+	Column      int          `json:"column,omitempty"`
+	Rule        string       `json:"rule,omitempty"`       // linter or rule ID which reports the error
+	Severity    string       `json:"severity,omitempty"`   // severity of error, empty if unknown
+	Confidence  string       `json:"confidence,omitempty"` // confidence of error, such as high of gosec, empty if unknown
+	ErrorString string       `json:"error_string"`
+	SourceLines []string     `json:"source_lines,omitempty"` // source lines of the error
+	Replacement *Replacement `json:"replacement,omitempty"`  // suggested fix, if any
+}
+
+// Replacement is a suggested fix to replace SourceLines,
+// the same as golangci-lint's Replacement.
+type Replacement struct {
+	NeedOnlyDelete bool               `json:"need_only_delete"`
+	NewLines       []string           `json:"new_lines"`
+	Inline         *InlineReplacement `json:"inline,omitempty"`
+}
+
+// InlineReplacement replaces Length bytes from StartCol (0-based)
+// of the first source line with NewString.
+type InlineReplacement struct {
+	StartCol  int    `json:"start_col"`
+	Length    int    `json:"length"`
+	NewString string `json:"new_string"`
+}
+
+// Fix returns source lines after the suggested fix applied, ok is false
+// if there is no suggested fix.
+func (e Error) Fix() (lines []string, ok bool) {
+	r := e.Replacement
+	switch {
+	case r == nil:
+		return nil, false
+	case r.NeedOnlyDelete:
+		return []string{}, true
+	case r.Inline != nil:
+		if len(e.SourceLines) == 0 {
+			return nil, false
+		}
+		line := e.SourceLines[0]
+		start, end := r.Inline.StartCol, r.Inline.StartCol+r.Inline.Length
+		if start < 0 || end > len(line) || start > end {
+			return nil, false
+		}
+		return []string{line[:start] + r.Inline.NewString + line[end:]}, true
+	}
+
+	return r.NewLines, true
 }
 
 // FileSummary contains the filename, location of the file
@@ -15,6 +61,7 @@ type FileSummary struct {
 	Filename string  `json:"filename"`
 	FileURL  string  `json:"file_url"`
 	Errors   []Error `json:"errors"`
+	Diff     string  `json:"diff,omitempty"` // unified diff to fix the file, if any
 }
 
 // AddError adds an Error to FileSummary
@@ -30,21 +77,198 @@ type Score struct {
 	Weight     float64       `json:"weight"`
 	Percentage float64       `json:"percentage"`
 	Error      string        `json:"error"`
+	State      ScoreState    `json:"state,omitempty"`
+
+	// Suppressed issues by `.goreportcard.yml` of repo, they are not counted in
+	// Summaries and percentage.
+	Suppressed []SuppressedIssue `json:"suppressed,omitempty"`
+	// Existing is count of issues on unchanged lines in diff-aware linting,
+	// they are not counted in Summaries and percentage.
+	Existing int `json:"existing,omitempty"`
+
+	Complexity *ComplexityStats `json:"complexity,omitempty"` // only complexity check
+	License    *LicenseInfo     `json:"license,omitempty"`    // only license check
+	Docs       *DocStats        `json:"docs,omitempty"`       // only doc check
+	Duplicates *DupStats        `json:"duplicates,omitempty"` // only duplicate check
+
+	// Dependencies of go.mod, only deps check, they are moved into
+	// LintResult.Dependencies after linted.
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+	// Vulnerabilities of dependencies, only vuln check, they are moved into
+	// LintResult.Vulnerabilities after linted.
+	Vulnerabilities []Vulnerability `json:"vulnerabilities,omitempty"`
+}
+
+// SuppressedIssue is an issue suppressed by `.goreportcard.yml` of repo
+type SuppressedIssue struct {
+	Filename string `json:"filename"`
+	FileURL  string `json:"file_url"`
+	Error    Error  `json:"error"`
+	Reason   string `json:"reason"` // reason of suppression
+}
+
+// ScoreState is the state of linter execution
+type ScoreState string
+
+const (
+	// ScoreOK means the linter finished, empty state of old reports is also OK.
+	ScoreOK ScoreState = "ok"
+	// ScoreFailed means the linter failed, see Score.Error.
+	ScoreFailed ScoreState = "failed"
+	// ScoreTimeout means the linter did not finish before deadline, the
+	// percentage is unknown and not counted in grade.
+	ScoreTimeout ScoreState = "timeout"
+)
+
+// LicenseInfo is the license detected in the root of repo
+type LicenseInfo struct {
+	SPDX       string  `json:"spdx"`       // SPDX identifier, empty if not recognized
+	File       string  `json:"file"`       // license file, empty if not found
+	Confidence float64 `json:"confidence"` // how much the license text matches
+}
+
+// Dependency is a module required by go.mod
+type Dependency struct {
+	Path       string `json:"path"`
+	Version    string `json:"version"`
+	Indirect   bool   `json:"indirect"`
+	Sum        bool   `json:"sum"`                  // hash of the version is in go.sum
+	Replace    string `json:"replace,omitempty"`    // replacement of the module, path[@version]
+	Latest     string `json:"latest,omitempty"`     // latest version of the same major version in proxy
+	Major      string `json:"major,omitempty"`      // module path of the newer major version, if any
+	Retracted  string `json:"retracted,omitempty"`  // rationale if the version is retracted
+	Deprecated string `json:"deprecated,omitempty"` // deprecation message of the module
+}
+
+// Vulnerability is a known vulnerability of a required module
+type Vulnerability struct {
+	ID        string   `json:"id"` // advisory ID, such as GO-2022-0969
+	Aliases   []string `json:"aliases,omitempty"`
+	Summary   string   `json:"summary"`
+	Module    string   `json:"module"`
+	Version   string   `json:"version"`           // required version which is affected
+	Fixed     string   `json:"fixed,omitempty"`   // the lowest fixed version, empty if not fixed
+	Reachable string   `json:"reachable"`         // symbol, package or module
+	Symbols   []string `json:"symbols,omitempty"` // vulnerable symbols referenced by code
+}
+
+// DocCounts counts documentation of exported identifiers and package clauses
+type DocCounts struct {
+	Exported   int `json:"exported"`
+	Documented int `json:"documented"` // which have doc comments
+	Misnamed   int `json:"misnamed"`   // doc comments which don't start with the name
+}
+
+// Add counts of other into c
+func (c *DocCounts) Add(other DocCounts) {
+	c.Exported += other.Exported
+	c.Documented += other.Documented
+	c.Misnamed += other.Misnamed
+}
+
+// Coverage is the share of documented, a doc comment which doesn't start
+// with the name counts half. It's 1 if nothing is exported.
+func (c DocCounts) Coverage() float64 {
+	if c.Exported == 0 {
+		return 1
+	}
+	return (float64(c.Documented) - float64(c.Misnamed)/2) / float64(c.Exported)
+}
+
+// PackageDocs is documentation coverage of a package
+type PackageDocs struct {
+	Dir string `json:"dir"`
+	DocCounts
+}
+
+// DocStats is documentation coverage of the repo
+type DocStats struct {
+	DocCounts
+	Packages []PackageDocs `json:"packages"`
+}
+
+// CloneLocation is a location of duplicated code
+type CloneLocation struct {
+	Filename  string `json:"filename"`
+	FileURL   string `json:"file_url"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+}
+
+// CloneGroup is code blocks which are copies of each other
+type CloneGroup struct {
+	Tokens    int             `json:"tokens"` // count of duplicated tokens
+	Locations []CloneLocation `json:"locations"`
+}
+
+// DupStats is duplicated code of the repo
+type DupStats struct {
+	Lines           int          `json:"lines"`            // lines of files checked
+	DuplicatedLines int          `json:"duplicated_lines"` // lines in any clone
+	Groups          []CloneGroup `json:"groups"`           // the most duplicated lines first
+}
+
+// Unique is the share of lines which are not duplicated, it's 1 if no
+// line is checked.
+func (s DupStats) Unique() float64 {
+	if s.Lines == 0 {
+		return 1
+	}
+	return 1 - float64(s.DuplicatedLines)/float64(s.Lines)
+}
+
+// SecurityScore is the sub-grade of security checks, such as gosec and vuln
+type SecurityScore struct {
+	Checks     []string `json:"checks"` // names of security checks
+	Percentage float64  `json:"percentage"`
+	Grade      Grade    `json:"grade"`
+	Issues     int      `json:"issues"`
+}
+
+// Distribution describes how values distribute in the repo
+type Distribution struct {
+	Average float64 `json:"average"`
+	P90     float64 `json:"p90"`
+	Max     float64 `json:"max"`
+}
+
+// ComplexityStats contains repo-wide distribution of functions' complexity
+type ComplexityStats struct {
+	Functions  int          `json:"functions"`
+	Cyclomatic Distribution `json:"cyclomatic"`
+	Cognitive  Distribution `json:"cognitive"`
 }
 
 // LintReport report structure of a lint process to some repository
 type LintReport struct {
-	Scores               []Score   `json:"scores"`
-	Average              float64   `json:"average"`
-	Grade                Grade     `json:"grade"`
-	FilesCount           int       `json:"files_count"`
-	IssuesCount          int       `json:"issues"`
-	Repo                 string    `json:"repo"`
-	ResolvedRepo         string    `json:"resolvedRepo"`
-	Branch               string    `json:"branch"`
-	LastRefresh          time.Time `json:"last_refresh"`
-	LastRefreshFormatted string    `json:"formatted_last_refresh"`
-	LastRefreshHumanized string    `json:"humanized_last_refresh"`
+	Scores               []Score         `json:"scores"`
+	Average              float64         `json:"average"`
+	Grade                Grade           `json:"grade"`
+	FilesCount           int             `json:"files_count"`
+	IssuesCount          int             `json:"issues"`
+	SuppressedCount      int             `json:"suppressed"`
+	Scoring              string          `json:"scoring"` // scoring strategy which produced the grade
+	Modules              []ModuleResult  `json:"modules,omitempty"`
+	TestScores           []Score         `json:"test_scores,omitempty"`
+	TestFilesCount       int             `json:"test_files_count"`
+	TestIssuesCount      int             `json:"test_issues"`
+	TestsGraded          bool            `json:"tests_graded"` // scores of test code are counted in grade or not
+	Excluded             []ExcludedPath  `json:"excluded,omitempty"`
+	GeneratedFiles       []string        `json:"generated_files,omitempty"`
+	Size                 *SizeStats      `json:"size,omitempty"`
+	Base                 string          `json:"base,omitempty"` // base ref of diff-aware linting, IssuesCount are new issues
+	ChangedFilesCount    int             `json:"changed_files,omitempty"`
+	ExistingIssuesCount  int             `json:"existing_issues,omitempty"`
+	Cache                *CacheStats     `json:"cache,omitempty"`
+	Dependencies         []Dependency    `json:"dependencies,omitempty"`
+	Vulnerabilities      []Vulnerability `json:"vulnerabilities,omitempty"`
+	Security             *SecurityScore  `json:"security,omitempty"`
+	Repo                 string          `json:"repo"`
+	ResolvedRepo         string          `json:"resolvedRepo"`
+	Branch               string          `json:"branch"`
+	LastRefresh          time.Time       `json:"last_refresh"`
+	LastRefreshFormatted string          `json:"formatted_last_refresh"`
+	LastRefreshHumanized string          `json:"humanized_last_refresh"`
 }
 
 // LintResult represents the combined result of multiple checks
@@ -54,6 +278,109 @@ type LintResult struct {
 	Grade   Grade   `json:"grade_from_percentage"`
 	Files   int     `json:"files"`
 	Issues  int     `json:"issues"`
+	// Suppressed is count of issues suppressed by `.goreportcard.yml`
+	Suppressed int    `json:"suppressed"`
+	Scoring    string `json:"scoring"` // scoring strategy which produced the grade
+
+	// Modules is the per-module breakdown of multi-module repo, empty if
+	// there is only one module in the root.
+	Modules []ModuleResult `json:"modules,omitempty"`
+
+	// TestScores are scores of test files which are linted in a separate
+	// pass, they are counted in Average only if TestsGraded.
+	TestScores  []Score `json:"test_scores,omitempty"`
+	TestFiles   int     `json:"test_files_count"`
+	TestIssues  int     `json:"test_issues"`
+	TestsGraded bool    `json:"tests_graded"`
+
+	// Excluded paths and the rules which excluded them
+	Excluded []ExcludedPath `json:"excluded,omitempty"`
+	// Generated files which are not linted, relative to root of repo
+	Generated []string `json:"generated_files,omitempty"`
+
+	// Size is code size of linted files, test files are not counted
+	Size *SizeStats `json:"size,omitempty"`
+
+	// Base is the base ref of diff-aware linting, Issues are new issues on
+	// changed lines, and ExistingIssues are issues on unchanged lines.
+	Base           string `json:"base,omitempty"`
+	ChangedFiles   int    `json:"changed_files,omitempty"`
+	ExistingIssues int    `json:"existing_issues,omitempty"`
+
+	// Cache is hits and misses of lint cache, nil if cache is not used
+	Cache *CacheStats `json:"cache,omitempty"`
+
+	// Dependencies of go.mod which are checked by deps check
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+	// Vulnerabilities of dependencies found by vuln check, the most
+	// reachable first
+	Vulnerabilities []Vulnerability `json:"vulnerabilities,omitempty"`
+
+	// Security is the sub-grade of security checks, nil if none is enabled
+	Security *SecurityScore `json:"security,omitempty"`
+}
+
+// CodeStats is code size of files
+type CodeStats struct {
+	Lines    int `json:"lines"`
+	Code     int `json:"code"`
+	Comments int `json:"comments"`
+	Blanks   int `json:"blanks"`
+	Exported int `json:"exported"` // count of exported symbols
+}
+
+// Add adds other into s
+func (s *CodeStats) Add(other CodeStats) {
+	s.Lines += other.Lines
+	s.Code += other.Code
+	s.Comments += other.Comments
+	s.Blanks += other.Blanks
+	s.Exported += other.Exported
+}
+
+// FileStats is code size of one file
+type FileStats struct {
+	Filename string `json:"filename"` // relative to root of repo
+	CodeStats
+}
+
+// PackageStats is code size of one package
+type PackageStats struct {
+	Dir   string `json:"dir"` // relative to root of repo
+	Files int    `json:"files"`
+	CodeStats
+}
+
+// SizeStats is code size of repo
+type SizeStats struct {
+	CodeStats
+	Packages []PackageStats `json:"packages"`
+	Files    []FileStats    `json:"files"`
+}
+
+// CacheStats counts (linter, package) pairs whose findings are reused from
+// lint cache or linted again.
+type CacheStats struct {
+	Hits   int `json:"hits"`
+	Misses int `json:"misses"`
+}
+
+// ExcludedPath is a path excluded from linting
+type ExcludedPath struct {
+	Path   string `json:"path"`   // relative to root of repo
+	Dir    bool   `json:"dir"`    // the path is a dir
+	Rule   string `json:"rule"`   // glob of the rule
+	Source string `json:"source"` // source of the rule: builtin, instance, repo or cli
+}
+
+// ModuleResult is the result of one module in multi-module repo
+type ModuleResult struct {
+	Path    string  `json:"path"` // module path declared in go.mod
+	Dir     string  `json:"dir"`  // dir of module relative to root of repo
+	Average float64 `json:"average"`
+	Grade   Grade   `json:"grade"`
+	Files   int     `json:"files"`
+	Issues  int     `json:"issues"`
 }
 
 // ByWeight implements sorting for checks by weight descending
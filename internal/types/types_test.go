@@ -0,0 +1,40 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLintResult_jsonNames(t *testing.T) {
+	// the same data in LintResult and LintReport should have the same json name
+	tests := []struct {
+		name   string
+		result string // field of LintResult
+		report string // field of LintReport
+	}{
+		{name: "case 0", result: "TestScores", report: "TestScores"},
+		{name: "case 1", result: "TestFiles", report: "TestFilesCount"},
+		{name: "case 2", result: "TestIssues", report: "TestIssuesCount"},
+		{name: "case 3", result: "TestsGraded", report: "TestsGraded"},
+		{name: "case 4", result: "Generated", report: "GeneratedFiles"},
+		{name: "case 5", result: "ChangedFiles", report: "ChangedFilesCount"},
+		{name: "case 6", result: "ExistingIssues", report: "ExistingIssuesCount"},
+		{name: "case 7", result: "Modules", report: "Modules"},
+		{name: "case 8", result: "Security", report: "Security"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := reflect.TypeOf(LintResult{}).FieldByName(tt.result)
+			if !ok {
+				t.Fatalf("LintResult has no field %s", tt.result)
+			}
+			report, ok := reflect.TypeOf(LintReport{}).FieldByName(tt.report)
+			if !ok {
+				t.Fatalf("LintReport has no field %s", tt.report)
+			}
+			if result.Tag.Get("json") != report.Tag.Get("json") {
+				t.Errorf("json of LintResult.%s = %q, LintReport.%s = %q", tt.result, result.Tag.Get("json"), tt.report, report.Tag.Get("json"))
+			}
+		})
+	}
+}